@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// manifestPath is the asset manifest's path inside the resources filesystem.
+const manifestPath = "assets.json"
+
+// TextureSpec describes one texture in the asset manifest. Sprite sheets set
+// a frame size and frame count; single images leave them zero and get one
+// frame covering the whole file.
+type TextureSpec struct {
+	Name        string `json:"name"`
+	Path        string `json:"path"` // Relative to the manifest
+	FrameWidth  int    `json:"frameWidth"`
+	FrameHeight int    `json:"frameHeight"`
+	Frames      int    `json:"frames"`
+}
+
+// Manifest lists every texture the game loads.
+type Manifest struct {
+	Textures []TextureSpec `json:"textures"`
+}
+
+// Texture is a loaded texture along with the spec it was loaded from.
+type Texture struct {
+	rl.Texture2D
+	Spec        TextureSpec
+	Placeholder bool // The file was missing or invalid and a checkerboard stands in
+}
+
+// Assets is the registry of everything loaded from the manifest.
+type Assets struct {
+	fsys     fs.FS
+	dir      string
+	textures map[string]*Texture
+	order    []string
+}
+
+func loadManifest(fsys fs.FS, path string) (*Manifest, error) {
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, fmt.Errorf("reading asset manifest: %w", err)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing asset manifest %s: %w", path, err)
+	}
+
+	seen := make(map[string]bool)
+	for i, spec := range m.Textures {
+		switch {
+		case spec.Name == "":
+			return nil, fmt.Errorf("asset manifest %s: texture %d has no name", path, i)
+		case spec.Path == "":
+			return nil, fmt.Errorf("asset manifest %s: texture %q has no path", path, spec.Name)
+		case seen[spec.Name]:
+			return nil, fmt.Errorf("asset manifest %s: texture %q is listed twice", path, spec.Name)
+		}
+		seen[spec.Name] = true
+	}
+	return &m, nil
+}
+
+// loadAssets loads every texture in the manifest at path in fsys. A texture
+// that can't be loaded or doesn't match its spec is replaced by a placeholder
+// and reported in the returned errors; the game keeps running either way.
+func loadAssets(fsys fs.FS, manifest string) (*Assets, []error) {
+	a := &Assets{
+		fsys:     fsys,
+		dir:      path.Dir(manifest),
+		textures: make(map[string]*Texture),
+	}
+
+	m, err := loadManifest(fsys, manifest)
+	if err != nil {
+		return a, []error{err}
+	}
+
+	var errs []error
+	for _, spec := range m.Textures {
+		tex, err := a.loadTexture(spec)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		a.textures[spec.Name] = tex
+		a.order = append(a.order, spec.Name)
+	}
+	return a, errs
+}
+
+func (a *Assets) loadTexture(spec TextureSpec) (*Texture, error) {
+	file := path.Join(a.dir, spec.Path)
+
+	data, err := fs.ReadFile(a.fsys, file)
+	if err != nil {
+		return placeholderTexture(spec), fmt.Errorf("texture %q: %w", spec.Name, err)
+	}
+
+	// Raylib decodes from memory, so textures work the same whether they
+	// come from the embedded copy or an override directory.
+	ext := strings.ToLower(path.Ext(file))
+	img := rl.LoadImageFromMemory(ext, data, int32(len(data)))
+	if img == nil || img.Width == 0 || img.Height == 0 {
+		return placeholderTexture(spec), fmt.Errorf("texture %q: %s is not a readable image", spec.Name, file)
+	}
+	defer rl.UnloadImage(img)
+
+	if err := spec.check(int(img.Width), int(img.Height)); err != nil {
+		return placeholderTexture(spec), fmt.Errorf("texture %q: %s: %w", spec.Name, file, err)
+	}
+
+	return &Texture{Texture2D: rl.LoadTextureFromImage(img), Spec: spec}, nil
+}
+
+// check makes sure an image of the given size can hold the frames the spec
+// asks for.
+func (spec TextureSpec) check(width, height int) error {
+	if spec.FrameWidth == 0 && spec.FrameHeight == 0 {
+		return nil
+	}
+	if spec.FrameWidth <= 0 || spec.FrameHeight <= 0 {
+		return fmt.Errorf("frame size %dx%d is invalid", spec.FrameWidth, spec.FrameHeight)
+	}
+	if width%spec.FrameWidth != 0 || height%spec.FrameHeight != 0 {
+		return fmt.Errorf("image is %dx%d, which is not a whole number of %dx%d frames",
+			width, height, spec.FrameWidth, spec.FrameHeight)
+	}
+	if have := (width / spec.FrameWidth) * (height / spec.FrameHeight); have < spec.Frames {
+		return fmt.Errorf("image is %dx%d, which holds %d frames of %dx%d but the manifest wants %d",
+			width, height, have, spec.FrameWidth, spec.FrameHeight, spec.Frames)
+	}
+	return nil
+}
+
+// placeholderTexture makes a magenta and black checkerboard the size the
+// spec expects, so a missing texture is obvious on screen instead of
+// silently drawing nothing.
+func placeholderTexture(spec TextureSpec) *Texture {
+	width, height := 64, 64
+	if spec.FrameWidth > 0 && spec.FrameHeight > 0 {
+		frames := max(spec.Frames, 1)
+		width, height = spec.FrameWidth*frames, spec.FrameHeight
+	}
+
+	img := rl.GenImageChecked(width, height, max(width/8, 1), max(height/8, 1), rl.Magenta, rl.Black)
+	defer rl.UnloadImage(img)
+
+	return &Texture{
+		Texture2D:   rl.LoadTextureFromImage(img),
+		Spec:        spec,
+		Placeholder: true,
+	}
+}
+
+// Texture returns the named texture. Names missing from the manifest get a
+// placeholder too, which is kept so it's unloaded with everything else.
+func (a *Assets) Texture(name string) rl.Texture2D {
+	return a.lookup(name).Texture2D
+}
+
+// Has reports whether the manifest lists the named texture.
+func (a *Assets) Has(name string) bool {
+	tex, ok := a.textures[name]
+	return ok && tex.Spec.Path != ""
+}
+
+// File returns the texture at path in the resources, loading it the first
+// time it's asked for. It's for images named by other data files, such as
+// the tilesets of a map, which don't need an entry in the manifest. A file
+// that can't be loaded gets a placeholder and an error.
+func (a *Assets) File(path string) (rl.Texture2D, error) {
+	if tex, ok := a.textures[path]; ok {
+		return tex.Texture2D, nil
+	}
+	tex, err := a.loadTexture(TextureSpec{Name: path, Path: path})
+	a.textures[path] = tex
+	a.order = append(a.order, path)
+	return tex.Texture2D, err
+}
+
+// Frame returns the source rectangle of frame i of the named texture, reading
+// sprite sheets left to right, top to bottom.
+func (a *Assets) Frame(name string, i int) rl.Rectangle {
+	tex := a.lookup(name)
+	fw, fh := tex.Spec.FrameWidth, tex.Spec.FrameHeight
+	if fw == 0 || fh == 0 {
+		return rl.NewRectangle(0, 0, float32(tex.Width), float32(tex.Height))
+	}
+
+	cols := max(int(tex.Width)/fw, 1)
+	return rl.NewRectangle(float32(i%cols*fw), float32(i/cols*fh), float32(fw), float32(fh))
+}
+
+func (a *Assets) lookup(name string) *Texture {
+	if tex, ok := a.textures[name]; ok {
+		return tex
+	}
+
+	assetsLog.Error("texture is not in the manifest", "texture", name)
+	tex := placeholderTexture(TextureSpec{Name: name})
+	a.textures[name] = tex
+	a.order = append(a.order, name)
+	return tex
+}
+
+// Unload frees every texture in the registry, placeholders included.
+func (a *Assets) Unload() {
+	for _, name := range a.order {
+		rl.UnloadTexture(a.textures[name].Texture2D)
+	}
+	a.textures = make(map[string]*Texture)
+	a.order = nil
+}
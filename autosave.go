@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io/fs"
+	"path/filepath"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+
+	"main/savefile"
+	"main/sim"
+)
+
+const (
+	autosaveInterval = 60.0 // Seconds between autosaves
+	autosaveBackups  = 3
+)
+
+var (
+	newGame = flag.Bool("new", false, "start a new game instead of resuming the autosave")
+
+	autosaves = savefile.Rotation{
+		Path:    filepath.Join(saveDir(), "autosave.json"),
+		Backups: autosaveBackups,
+	}
+	lastAutosave    float64
+	autosaveRunning bool
+
+	// Autosaves are written on a background goroutine so a slow disk never
+	// stalls a frame. The channel holds at most one pending save; if the
+	// writer is still busy, the newer snapshot replaces the waiting one.
+	autosaveQueue = make(chan []byte, 1)
+	autosaveDone  = make(chan struct{})
+)
+
+// startAutosave resumes from the newest valid autosave, unless -new or -seed
+// asked for a new world, and starts the background writer.
+func startAutosave() {
+	if !*newGame && !seedGiven() {
+		resumeAutosave()
+	}
+	lastAutosave = rl.GetTime()
+	autosaveRunning = true
+
+	go func() {
+		defer close(autosaveDone)
+		for data := range autosaveQueue {
+			if err := autosaves.Write(data); err != nil {
+				saveLog.Error("autosave failed", "err", err)
+			}
+		}
+	}()
+}
+
+func resumeAutosave() {
+	var d sim.SaveData
+	_, path, skipped, err := autosaves.ReadNewest(func(data []byte) error {
+		var err error
+		d, err = sim.DecodeSave(data)
+		return err
+	})
+	for _, err := range skipped {
+		saveLog.Warn("skipping damaged autosave", "err", err)
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		saveLog.Error("could not resume", "err", err)
+		showStatus("Autosave damaged, starting a new game")
+		return
+	}
+
+	world.Load(d)
+	saveLog.Info("resumed from autosave", "path", path, "seed", d.Seed)
+	if len(skipped) > 0 {
+		showStatus("Autosave damaged, restored a backup")
+	}
+}
+
+// autosave queues a snapshot of the world when the interval has passed.
+func autosave() {
+	if !autosaveRunning || rl.GetTime()-lastAutosave < autosaveInterval {
+		return
+	}
+	lastAutosave = rl.GetTime()
+
+	data, err := sim.EncodeSave(world)
+	if err != nil {
+		saveLog.Error("autosave failed", "err", err)
+		return
+	}
+
+	select {
+	case <-autosaveQueue: // Drop the stale snapshot the writer hasn't reached
+	default:
+	}
+	autosaveQueue <- data
+}
+
+// stopAutosave waits for the background writer, then writes one last
+// autosave so closing the window never loses progress.
+func stopAutosave() {
+	if !autosaveRunning {
+		return
+	}
+	autosaveRunning = false
+	close(autosaveQueue)
+	<-autosaveDone
+
+	data, err := sim.EncodeSave(world)
+	if err == nil {
+		err = autosaves.Write(data)
+	}
+	if err != nil {
+		saveLog.Error("autosave failed", "err", err)
+	}
+}
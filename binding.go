@@ -0,0 +1,265 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+type bindingKind int
+
+const (
+	bindKey bindingKind = iota
+	bindMouse
+	bindButton
+	bindAxis
+)
+
+var bindingKindNames = map[bindingKind]string{
+	bindKey:    "key",
+	bindMouse:  "mouse",
+	bindButton: "button",
+	bindAxis:   "axis",
+}
+
+// Binding is one control that can trigger an action. In config files it is
+// written as kind:name, for example "key:Space", "mouse:Left",
+// "mouse:WheelUp", "button:A" or "axis:LeftY-". Axis bindings name a stick
+// axis and the direction that counts. Keys can need modifiers held too, as
+// in "key:Ctrl+1".
+type Binding struct {
+	kind bindingKind
+	code int32
+	dir  float32  // Axis direction, +1 or -1
+	mods modifier // Modifier keys that must be held with a key
+}
+
+// modifier is a set of modifier keys, either side of the keyboard counting.
+type modifier int
+
+const (
+	modCtrl modifier = 1 << iota
+	modAlt
+	modShift
+)
+
+var modifierKeys = []struct {
+	name        string
+	mod         modifier
+	left, right int32
+}{
+	{"Ctrl", modCtrl, rl.KeyLeftControl, rl.KeyRightControl},
+	{"Alt", modAlt, rl.KeyLeftAlt, rl.KeyRightAlt},
+	{"Shift", modShift, rl.KeyLeftShift, rl.KeyRightShift},
+}
+
+// held reports whether every modifier in m is down.
+func (m modifier) held() bool {
+	for _, k := range modifierKeys {
+		if m&k.mod != 0 && !rl.IsKeyDown(k.left) && !rl.IsKeyDown(k.right) {
+			return false
+		}
+	}
+	return true
+}
+
+// lookupModifier finds a modifier by name, ignoring case.
+func lookupModifier(name string) (modifier, bool) {
+	for _, k := range modifierKeys {
+		if strings.EqualFold(k.name, name) {
+			return k.mod, true
+		}
+	}
+	return 0, false
+}
+
+var keyNames = map[string]int32{
+	"Space": rl.KeySpace, "Enter": rl.KeyEnter, "Escape": rl.KeyEscape, "Tab": rl.KeyTab,
+	"Backspace": rl.KeyBackspace, "Insert": rl.KeyInsert, "Delete": rl.KeyDelete,
+	"Up": rl.KeyUp, "Down": rl.KeyDown, "Left": rl.KeyLeft, "Right": rl.KeyRight,
+	"PageUp": rl.KeyPageUp, "PageDown": rl.KeyPageDown, "Home": rl.KeyHome, "End": rl.KeyEnd,
+	"LeftShift": rl.KeyLeftShift, "RightShift": rl.KeyRightShift,
+	"LeftControl": rl.KeyLeftControl, "RightControl": rl.KeyRightControl,
+	"LeftAlt": rl.KeyLeftAlt, "RightAlt": rl.KeyRightAlt,
+	"Grave": rl.KeyGrave, "Minus": rl.KeyMinus, "Equal": rl.KeyEqual,
+	"Comma": rl.KeyComma, "Period": rl.KeyPeriod, "Slash": rl.KeySlash,
+	"Semicolon": rl.KeySemicolon, "Apostrophe": rl.KeyApostrophe,
+}
+
+// The mouse wheel has no button code in raylib, so it gets codes of its own
+// well clear of the real buttons.
+const (
+	mouseWheelUp int32 = 100 + iota
+	mouseWheelDown
+)
+
+var mouseNames = map[string]int32{
+	"Left":      int32(rl.MouseButtonLeft),
+	"Right":     int32(rl.MouseButtonRight),
+	"Middle":    int32(rl.MouseButtonMiddle),
+	"Side":      int32(rl.MouseButtonSide),
+	"Extra":     int32(rl.MouseButtonExtra),
+	"WheelUp":   mouseWheelUp,
+	"WheelDown": mouseWheelDown,
+}
+
+// Gamepad buttons use Xbox names, which raylib maps onto every pad layout.
+var buttonNames = map[string]int32{
+	"DpadUp": rl.GamepadButtonLeftFaceUp, "DpadDown": rl.GamepadButtonLeftFaceDown,
+	"DpadLeft": rl.GamepadButtonLeftFaceLeft, "DpadRight": rl.GamepadButtonLeftFaceRight,
+	"A": rl.GamepadButtonRightFaceDown, "B": rl.GamepadButtonRightFaceRight,
+	"X": rl.GamepadButtonRightFaceLeft, "Y": rl.GamepadButtonRightFaceUp,
+	"LB": rl.GamepadButtonLeftTrigger1, "LT": rl.GamepadButtonLeftTrigger2,
+	"RB": rl.GamepadButtonRightTrigger1, "RT": rl.GamepadButtonRightTrigger2,
+	"Back": rl.GamepadButtonMiddleLeft, "Guide": rl.GamepadButtonMiddle, "Start": rl.GamepadButtonMiddleRight,
+	"LeftStick": rl.GamepadButtonLeftThumb, "RightStick": rl.GamepadButtonRightThumb,
+}
+
+var axisNames = map[string]int32{
+	"LeftX": rl.GamepadAxisLeftX, "LeftY": rl.GamepadAxisLeftY,
+	"RightX": rl.GamepadAxisRightX, "RightY": rl.GamepadAxisRightY,
+	"LeftTrigger": rl.GamepadAxisLeftTrigger, "RightTrigger": rl.GamepadAxisRightTrigger,
+}
+
+func init() {
+	for c := 'A'; c <= 'Z'; c++ {
+		keyNames[string(c)] = rl.KeyA + (c - 'A')
+	}
+	for c := '0'; c <= '9'; c++ {
+		keyNames[string(c)] = rl.KeyZero + (c - '0')
+	}
+	for i := int32(1); i <= 12; i++ {
+		keyNames[fmt.Sprintf("F%d", i)] = rl.KeyF1 + i - 1
+	}
+}
+
+// ParseBinding parses a binding written as kind:name.
+func ParseBinding(s string) (Binding, error) {
+	kind, name, ok := strings.Cut(s, ":")
+	if !ok {
+		return Binding{}, fmt.Errorf("binding %q should look like kind:name", s)
+	}
+
+	switch kind {
+	case "key":
+		var mods modifier
+		for {
+			prefix, rest, ok := strings.Cut(name, "+")
+			if !ok || rest == "" {
+				break
+			}
+			mod, ok := lookupModifier(prefix)
+			if !ok {
+				return Binding{}, fmt.Errorf("binding %q: unknown modifier %q (want Ctrl, Alt or Shift)", s, prefix)
+			}
+			mods |= mod
+			name = rest
+		}
+		if code, ok := lookupName(keyNames, name); ok {
+			return Binding{kind: bindKey, code: code, mods: mods}, nil
+		}
+	case "mouse":
+		if code, ok := lookupName(mouseNames, name); ok {
+			return Binding{kind: bindMouse, code: code}, nil
+		}
+	case "button":
+		if code, ok := lookupName(buttonNames, name); ok {
+			return Binding{kind: bindButton, code: code}, nil
+		}
+	case "axis":
+		dir := float32(1)
+		switch {
+		case strings.HasSuffix(name, "+"):
+			name = strings.TrimSuffix(name, "+")
+		case strings.HasSuffix(name, "-"):
+			name = strings.TrimSuffix(name, "-")
+			dir = -1
+		}
+		if code, ok := lookupName(axisNames, name); ok {
+			return Binding{kind: bindAxis, code: code, dir: dir}, nil
+		}
+	default:
+		return Binding{}, fmt.Errorf("binding %q: unknown kind %q (want key, mouse, button or axis)", s, kind)
+	}
+	return Binding{}, fmt.Errorf("binding %q: unknown %s %q", s, kind, name)
+}
+
+func lookupName(names map[string]int32, name string) (int32, bool) {
+	for n, code := range names {
+		if strings.EqualFold(n, name) {
+			return code, true
+		}
+	}
+	return 0, false
+}
+
+func (b Binding) String() string {
+	names := map[bindingKind]map[string]int32{
+		bindKey:    keyNames,
+		bindMouse:  mouseNames,
+		bindButton: buttonNames,
+		bindAxis:   axisNames,
+	}[b.kind]
+
+	name := fmt.Sprint(b.code)
+	for n, code := range names {
+		if code == b.code {
+			name = n
+			break
+		}
+	}
+	for i := len(modifierKeys) - 1; i >= 0; i-- {
+		if k := modifierKeys[i]; b.mods&k.mod != 0 {
+			name = k.name + "+" + name
+		}
+	}
+	if b.kind == bindAxis {
+		if b.dir < 0 {
+			name += "-"
+		} else {
+			name += "+"
+		}
+	}
+	return bindingKindNames[b.kind] + ":" + name
+}
+
+// value reads the control, from 0 (released) to 1 (fully held).
+func (b Binding) value(gamepad int32, deadzone float32) float32 {
+	switch b.kind {
+	case bindKey:
+		if rl.IsKeyDown(b.code) && b.mods.held() {
+			return 1
+		}
+	case bindMouse:
+		switch b.code {
+		case mouseWheelUp:
+			if rl.GetMouseWheelMove() > 0 {
+				return 1
+			}
+		case mouseWheelDown:
+			if rl.GetMouseWheelMove() < 0 {
+				return 1
+			}
+		default:
+			if rl.IsMouseButtonDown(rl.MouseButton(b.code)) {
+				return 1
+			}
+		}
+	case bindButton:
+		if rl.IsGamepadAvailable(gamepad) && rl.IsGamepadButtonDown(gamepad, b.code) {
+			return 1
+		}
+	case bindAxis:
+		if !rl.IsGamepadAvailable(gamepad) {
+			return 0
+		}
+		v := rl.GetGamepadAxisMovement(gamepad, b.code) * b.dir
+		if v <= deadzone {
+			return 0
+		}
+		// Rescale so the edge of the deadzone is 0 rather than a jump.
+		return min((v-deadzone)/(1-deadzone), 1)
+	}
+	return 0
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+
+	"main/sim"
+)
+
+const (
+	stumpFrame = 22 // In the grassBiome sheet
+	stumpScale = 3
+
+	// The axe swing frames in the tools sheet, raised to lowered.
+	axeSwingLeft  = 14
+	axeSwingRight = 17
+	axeSwingSteps = 3
+)
+
+// shakeOffset is how far a tree that was just hit is pushed sideways.
+func shakeOffset(tree sim.Tree) float32 {
+	if tree.Shake <= 0 {
+		return 0
+	}
+	return float32(math.Sin(float64(tree.Shake)*60)) * 6 * tree.Shake / sim.ShakeTime
+}
+
+// drawStump draws what's left of a felled tree, standing on its base.
+func drawStump(tree sim.Tree) {
+	src := assets.Frame("grassBiome", stumpFrame)
+	width, height := src.Width*stumpScale, src.Height*stumpScale
+	dest := rl.NewRectangle(tree.Position.X-width/2+shakeOffset(tree), tree.Position.Y-height, width, height)
+	rl.DrawTexturePro(assets.Texture("grassBiome"), src, dest, rl.Vector2{}, 0, rl.White)
+}
+
+// drawSwing draws the axe in the player's hands while a swing is under way,
+// beside them on the side they face.
+func drawSwing(player *sim.Player, playerDest rl.Rectangle) {
+	if player.SwingTime <= 0 {
+		return
+	}
+
+	progress := 1 - player.SwingTime/sim.SwingTime
+	step := min(int(progress*axeSwingSteps), axeSwingSteps-1)
+
+	size := playerDest.Width / 3
+	centerX := playerDest.X + playerDest.Width/2
+	y := playerDest.Y + playerDest.Height/2 - size/2
+	frame, x := axeSwingRight-step, centerX
+	if player.Dir == 1 || player.Dir == 2 { // Up or left
+		frame, x = axeSwingLeft-step, centerX-size
+	}
+
+	src := assets.Frame("tools", frame)
+	dest := rl.NewRectangle(x, y, size, size)
+	rl.DrawTexturePro(assets.Texture("tools"), src, dest, rl.Vector2{}, 0, rl.White)
+}
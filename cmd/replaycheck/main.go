@@ -0,0 +1,69 @@
+// Command replaycheck plays recordings back headless and reports whether
+// they still produce the recorded world, so CI can catch changes that break
+// determinism without a display.
+//
+//	go run ./cmd/replaycheck recording.jsonl...
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"main/replay"
+	"main/res"
+	"main/sim"
+	"main/tilemap"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: replaycheck recording...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	items, err := sim.LoadItems(res.FS)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	failed := false
+	for _, path := range flag.Args() {
+		if err := check(path, items); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func check(path string, items *sim.ItemRegistry) error {
+	rp, err := replay.Open(path)
+	if err != nil {
+		return err
+	}
+
+	var tiles *tilemap.Map
+	if path := rp.Header.Config.MapPath; path != "" {
+		if tiles, err = tilemap.Load(res.FS, path); err != nil {
+			return err
+		}
+	}
+
+	world := rp.NewWorld(items, tiles)
+	for !rp.Done() {
+		if err := rp.Step(world); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("%s: ok, %d ticks, final state %016x\n", path, rp.Tick(), world.Hash())
+	return nil
+}
@@ -0,0 +1,277 @@
+// Package console is the developer console's command registry. It knows
+// nothing about drawing or the game: subsystems register commands on a
+// Registry, and the front end feeds it lines the user typed.
+package console
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Command is one console command.
+type Command struct {
+	Name string
+	Args string // Argument synopsis for help, like "<item> <count>"
+	Help string // One-line description
+
+	// Run executes the command. args doesn't include the command name. The
+	// returned text is printed to the console.
+	Run func(args []string) (string, error)
+
+	// Complete, if set, returns candidates for the argument being typed.
+	// args holds the arguments so far, the last one possibly partial or
+	// empty.
+	Complete func(args []string) []string
+}
+
+// ErrUsage can be returned, or wrapped, by Run when the arguments are wrong.
+// The console then prints the command's synopsis.
+var ErrUsage = errors.New("wrong arguments")
+
+// Registry holds every registered command.
+type Registry struct {
+	cmds map[string]*Command
+}
+
+// NewRegistry returns a registry holding only the built-in help command.
+func NewRegistry() *Registry {
+	r := &Registry{cmds: make(map[string]*Command)}
+	r.Register(Command{
+		Name: "help",
+		Args: "[command]",
+		Help: "list commands, or describe one",
+		Run:  r.help,
+		Complete: func(args []string) []string {
+			if len(args) == 1 {
+				return r.names()
+			}
+			return nil
+		},
+	})
+	return r
+}
+
+// Register adds a command. Registering a name twice is a bug and panics.
+func (r *Registry) Register(c Command) {
+	if c.Name == "" || strings.ContainsAny(c.Name, " \t") {
+		panic(fmt.Sprintf("console: invalid command name %q", c.Name))
+	}
+	if c.Run == nil {
+		panic(fmt.Sprintf("console: command %q has no Run", c.Name))
+	}
+	if _, ok := r.cmds[c.Name]; ok {
+		panic(fmt.Sprintf("console: command %q registered twice", c.Name))
+	}
+	r.cmds[c.Name] = &c
+}
+
+// Commands returns every command, sorted by name.
+func (r *Registry) Commands() []Command {
+	cmds := make([]Command, 0, len(r.cmds))
+	for _, c := range r.cmds {
+		cmds = append(cmds, *c)
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+	return cmds
+}
+
+func (r *Registry) names() []string {
+	names := make([]string, 0, len(r.cmds))
+	for name := range r.cmds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Execute runs one line of input.
+func (r *Registry) Execute(line string) (string, error) {
+	fields, err := Split(line)
+	if err != nil {
+		return "", err
+	}
+	if len(fields) == 0 {
+		return "", nil
+	}
+
+	c, ok := r.cmds[fields[0]]
+	if !ok {
+		return "", fmt.Errorf("unknown command %q, try help", fields[0])
+	}
+
+	out, err := c.Run(fields[1:])
+	if errors.Is(err, ErrUsage) {
+		return out, fmt.Errorf("%w; usage: %s", err, synopsis(c))
+	}
+	return out, err
+}
+
+func (r *Registry) help(args []string) (string, error) {
+	if len(args) > 1 {
+		return "", ErrUsage
+	}
+	if len(args) == 1 {
+		c, ok := r.cmds[args[0]]
+		if !ok {
+			return "", fmt.Errorf("unknown command %q", args[0])
+		}
+		return synopsis(c) + "\n  " + c.Help, nil
+	}
+
+	var b strings.Builder
+	for i, c := range r.Commands() {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "%-32s %s", synopsis(&c), c.Help)
+	}
+	return b.String(), nil
+}
+
+func synopsis(c *Command) string {
+	if c.Args == "" {
+		return c.Name
+	}
+	return c.Name + " " + c.Args
+}
+
+// Complete returns the candidate completions for the last word of line,
+// each as the full line it would become.
+func (r *Registry) Complete(line string) []string {
+	fields, err := Split(line)
+	if err != nil {
+		return nil
+	}
+	// A trailing space means a new, empty word is being started.
+	if len(fields) == 0 || strings.HasSuffix(line, " ") {
+		fields = append(fields, "")
+	}
+
+	var candidates []string
+	if len(fields) == 1 {
+		candidates = r.names()
+	} else if c, ok := r.cmds[fields[0]]; ok && c.Complete != nil {
+		candidates = c.Complete(fields[1:])
+	}
+
+	partial := fields[len(fields)-1]
+	prefix := strings.Join(fields[:len(fields)-1], " ")
+	if prefix != "" {
+		prefix += " "
+	}
+
+	var lines []string
+	for _, cand := range candidates {
+		if strings.HasPrefix(cand, partial) {
+			lines = append(lines, prefix+cand)
+		}
+	}
+	return slices.Compact(lines)
+}
+
+// CommonPrefix returns the longest prefix shared by every string. It's
+// compared a rune at a time, so it never ends partway through one.
+func CommonPrefix(s []string) string {
+	if len(s) == 0 {
+		return ""
+	}
+	prefix := s[0]
+	for _, x := range s[1:] {
+		n := 0
+		for n < len(prefix) {
+			_, size := utf8.DecodeRuneInString(prefix[n:])
+			if !strings.HasPrefix(x[n:], prefix[n:n+size]) {
+				break
+			}
+			n += size
+		}
+		prefix = prefix[:n]
+	}
+	return prefix
+}
+
+// Split breaks a line into words on whitespace. Double quotes group words
+// that contain spaces.
+func Split(line string) ([]string, error) {
+	var (
+		fields  []string
+		cur     strings.Builder
+		inWord  bool
+		inQuote bool
+	)
+	for _, r := range line {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			inWord = true
+		case !inQuote && (r == ' ' || r == '\t'):
+			if inWord {
+				fields = append(fields, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		default:
+			cur.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inQuote {
+		return nil, errors.New("unterminated quote")
+	}
+	if inWord {
+		fields = append(fields, cur.String())
+	}
+	return fields, nil
+}
+
+// History remembers entered lines for recall with the arrow keys.
+type History struct {
+	lines []string
+	pos   int // Index into lines while browsing; len(lines) when not
+	Max   int // Lines kept; 0 means 100
+}
+
+// Add records a line and stops browsing. Repeats of the last line aren't
+// stored twice.
+func (h *History) Add(line string) {
+	if line != "" && (len(h.lines) == 0 || h.lines[len(h.lines)-1] != line) {
+		h.lines = append(h.lines, line)
+		if limit := orDefault(h.Max, 100); len(h.lines) > limit {
+			h.lines = h.lines[len(h.lines)-limit:]
+		}
+	}
+	h.pos = len(h.lines)
+}
+
+// Prev moves to the previous line, returning it and whether there was one.
+func (h *History) Prev() (string, bool) {
+	if h.pos == 0 {
+		return "", false
+	}
+	h.pos--
+	return h.lines[h.pos], true
+}
+
+// Next moves to the next line. Moving past the newest line returns "" so
+// the input can be cleared.
+func (h *History) Next() (string, bool) {
+	if h.pos >= len(h.lines) {
+		return "", false
+	}
+	h.pos++
+	if h.pos == len(h.lines) {
+		return "", true
+	}
+	return h.lines[h.pos], true
+}
+
+func orDefault(v, def int) int {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
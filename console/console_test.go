@@ -0,0 +1,133 @@
+package console
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    []string
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"   ", nil, false},
+		{"give", []string{"give"}, false},
+		{"give pinecone 5", []string{"give", "pinecone", "5"}, false},
+		{"  give\tpinecone   5 ", []string{"give", "pinecone", "5"}, false},
+		{`say "hello world"`, []string{"say", "hello world"}, false},
+		{`say "" x`, []string{"say", "", "x"}, false},
+		{`say a"b c"d`, []string{"say", "ab cd"}, false},
+		{`say "café au lait"`, []string{"say", "café au lait"}, false},
+		{`say "hello`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, err := Split(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Split error = %v, want error %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Split = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func testRegistry() *Registry {
+	r := NewRegistry()
+	run := func([]string) (string, error) { return "", nil }
+	r.Register(Command{
+		Name: "give",
+		Run:  run,
+		Complete: func(args []string) []string {
+			if len(args) == 1 {
+				return []string{"axe", "crystal", "pinecone"}
+			}
+			return nil
+		},
+	})
+	r.Register(Command{Name: "growth", Run: run})
+	r.Register(Command{Name: "inventory", Run: run, Complete: func(args []string) []string {
+		if len(args) == 1 {
+			return []string{"move", "split", "split"}
+		}
+		return nil
+	}})
+	return r
+}
+
+func TestComplete(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"", []string{"give", "growth", "help", "inventory"}},
+		{"g", []string{"give", "growth"}},
+		{"gi", []string{"give"}},
+		{"give", []string{"give"}},
+		{"x", nil},
+		{"give ", []string{"give axe", "give crystal", "give pinecone"}},
+		{"give c", []string{"give crystal"}},
+		{"give  c", []string{"give crystal"}},
+		{"give crystal ", nil},
+		{"growth ", nil},
+		{"inventory s", []string{"inventory split"}},
+		{"help gr", []string{"help growth"}},
+		{"unknown ", nil},
+		{`give "c`, nil},
+	}
+	r := testRegistry()
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			if got := r.Complete(tt.line); !slices.Equal(got, tt.want) {
+				t.Errorf("Complete(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommonPrefix(t *testing.T) {
+	tests := []struct {
+		name string
+		s    []string
+		want string
+	}{
+		{"none", nil, ""},
+		{"one", []string{"give"}, "give"},
+		{"shared", []string{"give", "growth"}, "g"},
+		{"one is a prefix", []string{"inventory split", "inventory"}, "inventory"},
+		{"nothing shared", []string{"give", "help"}, ""},
+		{"multibyte", []string{"café", "cafés"}, "café"},
+		{"same first byte", []string{"é", "è"}, ""}, // Both start with 0xC3
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CommonPrefix(tt.s); got != tt.want {
+				t.Errorf("CommonPrefix(%q) = %q, want %q", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistory(t *testing.T) {
+	var h History
+	for _, line := range []string{"give axe", "help", "help"} {
+		h.Add(line)
+	}
+	var got []string
+	for {
+		line, ok := h.Prev()
+		if !ok {
+			break
+		}
+		got = append(got, line)
+	}
+	if want := []string{"help", "give axe"}; !slices.Equal(got, want) {
+		t.Errorf("Prev went through %q, want %q", got, want)
+	}
+	if line, ok := h.Next(); !ok || line != "help" {
+		t.Errorf("Next = %q, %v, want %q", line, ok, "help")
+	}
+}
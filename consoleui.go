@@ -0,0 +1,273 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+
+	"main/console"
+	"main/sim"
+)
+
+// The developer console, opened with the backtick key. Commands come from
+// the registry, where each subsystem adds its own.
+
+const consoleScrollback = 200
+
+var (
+	commands = console.NewRegistry()
+
+	consoleOpen    bool
+	consoleInput   string
+	consoleLines   []string
+	consoleHistory console.History
+)
+
+func registerCommands() {
+	sim.RegisterCommands(commands, items, func() (*sim.World, error) {
+		if recordingOrReplaying() {
+			return nil, errors.New("the world can't be changed from the console while recording or replaying")
+		}
+		return world, nil
+	})
+
+	slotArg := func(args []string) (string, error) {
+		switch len(args) {
+		case 0:
+			return quickSaveSlot, nil
+		case 1:
+			return args[0], nil
+		}
+		return "", console.ErrUsage
+	}
+	commands.Register(console.Command{
+		Name: "save",
+		Args: "[slot]",
+		Help: "save the game, to the quick save slot if none is named",
+		Run: func(args []string) (string, error) {
+			slot, err := slotArg(args)
+			if err != nil {
+				return "", err
+			}
+			if err := saveGame(slot); err != nil {
+				return "", err
+			}
+			return "saved to " + slot, nil
+		},
+	})
+	commands.Register(console.Command{
+		Name: "load",
+		Args: "[slot]",
+		Help: "load a saved game, the quick save if no slot is named",
+		Run: func(args []string) (string, error) {
+			slot, err := slotArg(args)
+			if err != nil {
+				return "", err
+			}
+			if err := loadGame(slot); err != nil {
+				return "", err
+			}
+			return "loaded " + slot, nil
+		},
+	})
+
+	registerBindingCommands()
+	registerMapCommands()
+}
+
+func registerBindingCommands() {
+	actionNames := func(args []string) []string {
+		if len(args) == 1 {
+			return actionNames[:]
+		}
+		return nil
+	}
+	parse := func(args []string) (Action, Binding, error) {
+		if len(args) != 2 {
+			return 0, Binding{}, console.ErrUsage
+		}
+		a, err := ParseAction(args[0])
+		if err != nil {
+			return 0, Binding{}, err
+		}
+		bind, err := ParseBinding(args[1])
+		return a, bind, err
+	}
+	save := func(msg string) (string, error) {
+		if err := bindings.Save(bindingsPath()); err != nil {
+			return "", err
+		}
+		return msg, nil
+	}
+
+	commands.Register(console.Command{
+		Name:     "bind",
+		Args:     "<action> <control>",
+		Help:     "add a control to an action, like bind Plant key:F or bind Splash button:B",
+		Complete: actionNames,
+		Run: func(args []string) (string, error) {
+			a, bind, err := parse(args)
+			if err != nil {
+				return "", err
+			}
+			bindings.Bind(a, bind)
+			return save(fmt.Sprintf("bound %v to %v", bind, a))
+		},
+	})
+	commands.Register(console.Command{
+		Name:     "unbind",
+		Args:     "<action> <control>",
+		Help:     "remove a control from an action",
+		Complete: actionNames,
+		Run: func(args []string) (string, error) {
+			a, bind, err := parse(args)
+			if err != nil {
+				return "", err
+			}
+			bindings.Unbind(a, bind)
+			return save(fmt.Sprintf("unbound %v from %v", bind, a))
+		},
+	})
+	commands.Register(console.Command{
+		Name:     "bindings",
+		Args:     "[action]",
+		Help:     "list the controls bound to every action, or to one",
+		Complete: actionNames,
+		Run: func(args []string) (string, error) {
+			var lines []string
+			for a := Action(0); a < actionCount; a++ {
+				if len(args) == 1 && !strings.EqualFold(args[0], a.String()) {
+					continue
+				}
+				var controls []string
+				for _, bind := range bindings.Controls(a) {
+					controls = append(controls, bind.String())
+				}
+				lines = append(lines, fmt.Sprintf("%-20s %s", a, strings.Join(controls, " ")))
+			}
+			if len(lines) == 0 {
+				return "", fmt.Errorf("unknown action %q", args[0])
+			}
+			return strings.Join(lines, "\n"), nil
+		},
+	})
+}
+
+func consolePrint(text string) {
+	consoleLines = append(consoleLines, strings.Split(text, "\n")...)
+	if len(consoleLines) > consoleScrollback {
+		consoleLines = consoleLines[len(consoleLines)-consoleScrollback:]
+	}
+}
+
+// updateConsole handles typing while the console is open. Call it every
+// frame after the bindings are polled.
+func updateConsole() {
+	if bindings.Pressed(ActionToggleConsole) {
+		consoleOpen = !consoleOpen
+	}
+	if !consoleOpen {
+		return
+	}
+
+	for c := rl.GetCharPressed(); c != 0; c = rl.GetCharPressed() {
+		if c == '`' || c == '~' {
+			continue // The toggle key
+		}
+		consoleInput += string(c)
+	}
+
+	pressed := func(key int32) bool {
+		return rl.IsKeyPressed(key) || rl.IsKeyPressedRepeat(key)
+	}
+	switch {
+	case pressed(rl.KeyBackspace) && consoleInput != "":
+		_, size := lastRune(consoleInput)
+		consoleInput = consoleInput[:len(consoleInput)-size]
+	case rl.IsKeyPressed(rl.KeyEnter):
+		runConsoleLine()
+	case pressed(rl.KeyUp):
+		if line, ok := consoleHistory.Prev(); ok {
+			consoleInput = line
+		}
+	case pressed(rl.KeyDown):
+		if line, ok := consoleHistory.Next(); ok {
+			consoleInput = line
+		}
+	case rl.IsKeyPressed(rl.KeyTab):
+		completeConsoleLine()
+	case rl.IsKeyPressed(rl.KeyEscape):
+		consoleOpen = false
+	}
+}
+
+func lastRune(s string) (rune, int) {
+	r := []rune(s)
+	last := r[len(r)-1]
+	return last, len(string(last))
+}
+
+func runConsoleLine() {
+	line := strings.TrimSpace(consoleInput)
+	consoleInput = ""
+	consoleHistory.Add(line)
+	if line == "" {
+		return
+	}
+
+	consolePrint("> " + line)
+	out, err := commands.Execute(line)
+	if out != "" {
+		consolePrint(out)
+	}
+	if err != nil {
+		consolePrint("error: " + err.Error())
+	}
+}
+
+// completeConsoleLine completes as far as every candidate agrees, and lists
+// the candidates when there's more than one.
+func completeConsoleLine() {
+	candidates := commands.Complete(consoleInput)
+	switch len(candidates) {
+	case 0:
+		return
+	case 1:
+		consoleInput = candidates[0] + " "
+	default:
+		consoleInput = console.CommonPrefix(candidates)
+		words := make([]string, len(candidates))
+		for i, c := range candidates {
+			words[i] = c[strings.LastIndex(c, " ")+1:]
+		}
+		consolePrint(strings.Join(words, "  "))
+	}
+}
+
+func drawConsole() {
+	if !consoleOpen {
+		return
+	}
+
+	const (
+		height   = screenHeight * 45 / 100
+		fontSize = 20
+		lineH    = 24
+		margin   = 12
+	)
+	rl.DrawRectangle(0, 0, screenWidth, height, rl.Fade(rl.Black, 0.8))
+
+	y := int32(height - margin - lineH)
+	prompt := "> " + consoleInput
+	if int(rl.GetTime()*2)%2 == 0 {
+		prompt += "_"
+	}
+	rl.DrawText(prompt, margin, y, fontSize, rl.White)
+
+	for i := len(consoleLines) - 1; i >= 0 && y > lineH; i-- {
+		y -= lineH
+		rl.DrawText(consoleLines[i], margin, y, fontSize, rl.LightGray)
+	}
+}
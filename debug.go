@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+
+	"main/sim"
+	"main/tilemap"
+)
+
+// The F3 debug overlay. Nothing here is drawn in normal play.
+
+const frameHistory = 240 // Frames shown in the frame time graph
+
+var (
+	debugVisible bool
+
+	frameTimes    [frameHistory]float32 // Seconds, oldest first once full
+	frameTimeNext int
+)
+
+func recordFrameTime(dt float32) {
+	frameTimes[frameTimeNext] = dt
+	frameTimeNext = (frameTimeNext + 1) % frameHistory
+}
+
+// drawDebugWorld draws markers in world space for what's in view. Call it
+// inside camera mode.
+func drawDebugWorld(alpha float32, view sim.Rect) {
+	// Draw player center point
+	playerDest := world.Player.InterpolatedDest(alpha)
+	playerCenter := rl.Vector2{X: playerDest.X + playerDest.Width/2, Y: playerDest.Y + playerDest.Height/2}
+	rl.DrawCircleV(playerCenter, 5, rl.Red)
+	rl.DrawRectangleLinesEx(rect(playerDest), 1, rl.Red)
+	rl.DrawCircleLines(int32(playerCenter.X), int32(playerCenter.Y), sim.MagnetRadius, rl.Orange)
+
+	// Draw interaction radius around world items
+	for _, i := range world.ItemsIn(view) {
+		pos, _ := world.WorldItems[i].InterpolatedPosition(alpha)
+		rl.DrawCircle(int32(pos.X), int32(pos.Y), 5, rl.Blue)
+		rl.DrawCircleLines(int32(pos.X), int32(pos.Y), sim.InteractionRadius, rl.Green)
+	}
+
+	for _, i := range world.TreesIn(view) {
+		tree := world.Trees[i]
+		rl.DrawCircle(int32(tree.Position.X), int32(tree.Position.Y), 5, rl.DarkGreen)
+		label := fmt.Sprintf("%v %.0f%%", tree.Stage, tree.StageProgress()*100)
+		switch {
+		case tree.Stump:
+			label = fmt.Sprintf("stump %.0f%%", tree.StageProgress()*100)
+		case tree.Stage == sim.StageMature:
+			label = fmt.Sprintf("%v, seed in %.0fs", tree.Stage, (sim.ConeInterval-tree.ConeTime)/world.GrowthSpeed)
+		}
+		if tree.Boost > 1 {
+			label += fmt.Sprintf(" x%.2f", tree.Boost)
+			rl.DrawCircleLines(int32(tree.Position.X), int32(tree.Position.Y), sim.BoostRadius, rl.SkyBlue)
+		}
+		rl.DrawText(label, int32(tree.Position.X)+8, int32(tree.Position.Y)+4, 16, rl.DarkGreen)
+	}
+
+	// Outline what blocks movement near the player, and the player's feet
+	feet := world.Player.Footprint()
+	near := sim.Rect{X: feet.X - 200, Y: feet.Y - 200, Width: feet.Width + 400, Height: feet.Height + 400}
+	for _, box := range world.Obstacles(near) {
+		rl.DrawRectangleLinesEx(rect(box), 1, rl.Maroon)
+	}
+	rl.DrawRectangleLinesEx(rect(feet), 1, rl.Magenta)
+
+	// Outline the tile under the cursor
+	tile := cursorTile()
+	tileW, tileH := mapTileSize()
+	rl.DrawRectangleLines(int32(float32(tile.X)*tileW), int32(float32(tile.Y)*tileH), int32(tileW), int32(tileH), rl.Yellow)
+}
+
+type tileCoord struct {
+	X, Y int
+}
+
+// cursorTile returns the map tile under the mouse.
+func cursorTile() tileCoord {
+	tileW, tileH := mapTileSize()
+	cursor := rl.GetScreenToWorld2D(virtualMouse(), camera)
+	return tileCoord{
+		X: int(math.Floor(float64(cursor.X / tileW))),
+		Y: int(math.Floor(float64(cursor.Y / tileH))),
+	}
+}
+
+// tileFlags lists the properties the game knows that are set on a map tile.
+func tileFlags(tile tileCoord) string {
+	if worldMap == nil {
+		return ""
+	}
+	var flags []string
+	for _, name := range []string{tilemap.PropSolid, tilemap.PropWater, tilemap.PropTillable} {
+		if worldMap.Flag(tile.X, tile.Y, name) {
+			flags = append(flags, name)
+		}
+	}
+	return strings.Join(flags, " ")
+}
+
+// drawDebugOverlay draws the text panel and frame time graph in screen
+// space.
+func drawDebugOverlay() {
+	const (
+		x        = 20
+		y        = 70
+		fontSize = 20
+		lineH    = 24
+		width    = 480
+	)
+
+	cursor := rl.GetScreenToWorld2D(virtualMouse(), camera)
+	tile := cursorTile()
+	player := world.Player.Center()
+
+	lines := []string{
+		fmt.Sprintf("FPS %d  frame %.1f ms", rl.GetFPS(), lastFrameTime()*1000),
+		fmt.Sprintf("tick %d  time %.1f s", world.Ticks, world.Time),
+		fmt.Sprintf("player %.0f, %.0f  dir %d", player.X, player.Y, world.Player.Dir),
+		fmt.Sprintf("camera %.0f, %.0f  zoom %.2f", camera.Target.X, camera.Target.Y, camera.Zoom),
+		fmt.Sprintf("cursor %.0f, %.0f  tile %d, %d  %s", cursor.X, cursor.Y, tile.X, tile.Y, tileFlags(tile)),
+		fmt.Sprintf("items %d  trees %d  particles %d",
+			len(world.WorldItems), len(world.Trees), len(world.Particles)),
+	}
+	if biome, ok := world.Biome(tile.X, tile.Y); ok {
+		loaded, stored := world.LoadedChunks()
+		chunk := tilemap.ChunkOf(tile.X, tile.Y)
+		lines = append(lines, fmt.Sprintf("%v  chunk %d, %d  loaded %d  kept %d", biome, chunk.X, chunk.Y, loaded, stored))
+	}
+
+	const graphH = 60
+	panelH := int32(len(lines)*lineH + graphH + 30)
+	rl.DrawRectangle(x-10, y-10, width, panelH, rl.Fade(rl.Black, 0.6))
+	for i, line := range lines {
+		rl.DrawText(line, x, y+int32(i*lineH), fontSize, rl.White)
+	}
+
+	drawFrameGraph(x, y+int32(len(lines)*lineH)+10, width-20, graphH)
+}
+
+func lastFrameTime() float32 {
+	return frameTimes[(frameTimeNext+frameHistory-1)%frameHistory]
+}
+
+// drawFrameGraph plots recent frame times as bars, with a line at the time
+// one simulation tick takes. Bars over it are frames slower than the tick
+// rate.
+func drawFrameGraph(x, y, width, height int32) {
+	const scale = 1.0 / 30 // Frame time that fills the graph's height
+
+	barW := max(width/frameHistory, 1)
+	for i := 0; i < frameHistory; i++ {
+		dt := frameTimes[(frameTimeNext+i)%frameHistory]
+		h := int32(min(dt/scale, 1) * float32(height))
+
+		c := rl.Green
+		if dt > fixedDt*1.05 {
+			c = rl.Orange
+		}
+		rl.DrawRectangle(x+int32(i)*barW, y+height-h, barW, h, c)
+	}
+
+	tickY := y + height - int32(fixedDt/scale*float32(height))
+	rl.DrawLine(x, tickY, x+barW*frameHistory, tickY, rl.White)
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// The game always draws at screenWidth x screenHeight, the virtual
+// resolution, into a render texture. The texture is then scaled to fit the
+// window, so the world and UI look the same at any window size.
+
+const (
+	scaleLetterbox = "letterbox" // Largest scale that fits, bars on two sides
+	scaleInteger   = "integer"   // Largest whole-number scale, for crisp pixels
+)
+
+// Settings are the display options saved in settings.json.
+type Settings struct {
+	Width      int    `json:"width"` // Window size when not fullscreen
+	Height     int    `json:"height"`
+	Fullscreen bool   `json:"fullscreen"`
+	Borderless bool   `json:"borderless"` // Borderless window covering the monitor
+	VSync      bool   `json:"vsync"`
+	Scaling    string `json:"scaling"` // "letterbox" or "integer"
+}
+
+var (
+	settings = defaultSettings()
+
+	target rl.RenderTexture2D // Everything is drawn here at the virtual resolution
+)
+
+func defaultSettings() Settings {
+	// Small enough to fit on a laptop screen.
+	return Settings{
+		Width:   1280,
+		Height:  720,
+		VSync:   true,
+		Scaling: scaleLetterbox,
+	}
+}
+
+func settingsPath() string {
+	return filepath.Join(configDir(), "settings.json")
+}
+
+// loadSettings reads settings.json, writing the defaults there if it doesn't
+// exist yet. Bad values are reported and replaced with defaults.
+func loadSettings(path string) (Settings, error) {
+	s := defaultSettings()
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, saveSettings(path, s)
+	}
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return defaultSettings(), fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	def := defaultSettings()
+	if s.Width < 320 || s.Height < 180 {
+		err = fmt.Errorf("%s: window size %dx%d is too small, using %dx%d", path, s.Width, s.Height, def.Width, def.Height)
+		s.Width, s.Height = def.Width, def.Height
+	}
+	if s.Scaling != scaleLetterbox && s.Scaling != scaleInteger {
+		err = fmt.Errorf("%s: unknown scaling %q, using %q", path, s.Scaling, def.Scaling)
+		s.Scaling = def.Scaling
+	}
+	return s, err
+}
+
+func saveSettings(path string, s Settings) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// openWindow creates the window from the settings, along with the render
+// texture the game draws into.
+func openWindow() {
+	flags := uint32(rl.FlagWindowResizable)
+	if settings.VSync {
+		flags |= rl.FlagVsyncHint
+	}
+	rl.SetConfigFlags(flags)
+
+	// Raylib logs every texture it loads; only its problems are interesting.
+	rl.SetTraceLogLevel(rl.LogWarning)
+
+	rl.InitWindow(int32(settings.Width), int32(settings.Height), "Totoro")
+	rl.SetWindowMinSize(320, 180)
+	rl.SetExitKey(0)
+	rl.SetTargetFPS(60)
+
+	switch {
+	case settings.Fullscreen:
+		monitor := rl.GetCurrentMonitor()
+		rl.SetWindowSize(rl.GetMonitorWidth(monitor), rl.GetMonitorHeight(monitor))
+		rl.ToggleFullscreen()
+	case settings.Borderless:
+		rl.ToggleBorderlessWindowed()
+	}
+
+	target = rl.LoadRenderTexture(screenWidth, screenHeight)
+	applyScalingFilter()
+}
+
+func closeWindow() {
+	rl.UnloadRenderTexture(target)
+	rl.CloseWindow()
+}
+
+// Point filtering keeps whole-number scales pixel sharp; fractional scales
+// look better smoothed.
+func applyScalingFilter() {
+	if settings.Scaling == scaleInteger {
+		rl.SetTextureFilter(target.Texture, rl.FilterPoint)
+	} else {
+		rl.SetTextureFilter(target.Texture, rl.FilterBilinear)
+	}
+}
+
+// toggleFullscreen switches between fullscreen and a normal window, and
+// remembers the choice.
+func toggleFullscreen() {
+	if settings.Borderless {
+		rl.ToggleBorderlessWindowed()
+		settings.Borderless = false
+	}
+
+	if rl.IsWindowFullscreen() {
+		rl.ToggleFullscreen()
+		rl.SetWindowSize(settings.Width, settings.Height)
+		settings.Fullscreen = false
+	} else {
+		monitor := rl.GetCurrentMonitor()
+		rl.SetWindowSize(rl.GetMonitorWidth(monitor), rl.GetMonitorHeight(monitor))
+		rl.ToggleFullscreen()
+		settings.Fullscreen = true
+	}
+
+	if err := saveSettings(settingsPath(), settings); err != nil {
+		displayLog.Error("saving settings", "err", err)
+	}
+}
+
+// rememberWindowSize keeps the settings in step when the player resizes the
+// window by hand.
+func rememberWindowSize() {
+	if !rl.IsWindowResized() || settings.Fullscreen || settings.Borderless {
+		return
+	}
+	settings.Width, settings.Height = rl.GetScreenWidth(), rl.GetScreenHeight()
+}
+
+// viewport is where the virtual screen lands in the window.
+func viewport() rl.Rectangle {
+	winW, winH := float32(rl.GetScreenWidth()), float32(rl.GetScreenHeight())
+
+	scale := min(winW/screenWidth, winH/screenHeight)
+	if settings.Scaling == scaleInteger && scale >= 1 {
+		scale = float32(math.Floor(float64(scale)))
+	}
+
+	w, h := screenWidth*scale, screenHeight*scale
+	return rl.NewRectangle(float32(math.Floor(float64((winW-w)/2))), float32(math.Floor(float64((winH-h)/2))), w, h)
+}
+
+// presentFrame scales the finished virtual screen into the window.
+func presentFrame() {
+	rl.BeginDrawing()
+	rl.ClearBackground(rl.Black)
+
+	// Render textures are stored upside down, hence the negative height.
+	src := rl.NewRectangle(0, 0, float32(target.Texture.Width), -float32(target.Texture.Height))
+	rl.DrawTexturePro(target.Texture, src, viewport(), rl.Vector2{}, 0, rl.White)
+
+	rl.EndDrawing()
+}
+
+// virtualMouse returns the mouse position in virtual screen coordinates.
+func virtualMouse() rl.Vector2 {
+	vp := viewport()
+	m := rl.GetMousePosition()
+	return rl.Vector2{
+		X: (m.X - vp.X) * screenWidth / vp.Width,
+		Y: (m.Y - vp.Y) * screenHeight / vp.Height,
+	}
+}
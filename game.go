@@ -1,793 +1,398 @@
 package main
 
 import (
-	"fmt"
-	"math"
-	"math/rand"
+	"flag"
 	"time"
 
 	rl "github.com/gen2brain/raylib-go/raylib"
+
+	"main/sim"
 )
 
 const (
+	// The virtual resolution everything is drawn at, whatever the window size.
 	screenWidth  = 1920 // Increased from 1000 to 1920 (standard HD width)
 	screenHeight = 1080 // Increased from 480 to 1080 (standard HD height)
+
+	tickRate     = 60 // Simulation steps per second
+	fixedDt      = float32(1) / tickRate
+	maxFrameTime = 0.25 // Longest frame we try to catch up on, in seconds
+
+	// The bag is drawn at bagScale of its texture's size, bagMargin virtual
+	// pixels in from the left and bottom edges of the screen.
+	bagScale  = 0.7
+	bagMargin = 100
 )
 
 var (
 	running  = true
 	bkgColor = rl.NewColor(147, 211, 196, 255)
 
-	grassSprite     rl.Texture2D
-	groundSprite    rl.Texture2D
-	playerSprite    rl.Texture2D
-	nestSprite      rl.Texture2D
-	creatureSprite  rl.Texture2D
-	stoneTileSprite rl.Texture2D
-	pineConeSprite  rl.Texture2D
-
-	playerSrc                                     rl.Rectangle
-	playerDest                                    rl.Rectangle
-	playerMoving                                  bool
-	playerDir                                     int
-	playerUp, playerDown, playerRight, playerLeft bool
-	playerFrame                                   int
-
-	frameCount int
-
-	playerSpeed float32 = 3
-
-	droppedPineCones []rl.Vector2
-
+	grassSprite    rl.Texture2D
+	playerSprite   rl.Texture2D
+	creatureSprite rl.Texture2D
 	pineTreeSprite rl.Texture2D
-	growingTrees   []struct {
-		position rl.Vector2
-		frame    int
-		growing  bool
-	}
-	treeAnimationSpeed int = 60 // Made even slower for more visible growth
 
 	camera rl.Camera2D // Add camera variable
 
-	pineConeCount int // Number of pine cones in inventory
-
-	bagBgSprite        rl.Texture2D
-	pineConeIconSprite rl.Texture2D
-
-	crystalStoneSprite   rl.Texture2D
-	crystalStoneCount    int
-	droppedCrystalStones []rl.Vector2
-
-	particles    []Particle
-	splashActive bool
+	bagBgSprite rl.Texture2D
 
 	cloudSprite rl.Texture2D
 
-	cloudsLayer1 []rl.Vector2 // Farthest, slowest
-	cloudsLayer2 []rl.Vector2 // Middle
-	cloudsLayer3 []rl.Vector2 // Closest, fastest
-)
+	assets *Assets
+	items  *sim.ItemRegistry
 
-type ItemType int
+	world *sim.World
 
-const (
-	ItemNone ItemType = iota
-	ItemPineCone
-	ItemCrystalStone // Add new item type
+	pendingInputs sim.Inputs // Input polled since the last simulation step
+	accumulator   float32    // Frame time not yet simulated
 )
 
-type InventorySlot struct {
-	Item  ItemType
-	Count int
+func vec(v sim.Vec2) rl.Vector2 {
+	return rl.Vector2{X: v.X, Y: v.Y}
+}
+
+func rect(r sim.Rect) rl.Rectangle {
+	return rl.Rectangle{X: r.X, Y: r.Y, Width: r.Width, Height: r.Height}
 }
 
-var inventory [4]InventorySlot
+func color(c sim.Color) rl.Color {
+	return rl.NewColor(c.R, c.G, c.B, c.A)
+}
 
-type Particle struct {
-	position rl.Vector2
-	velocity rl.Vector2
-	color    rl.Color
-	size     float32
-	life     float32
-	maxLife  float32
+// drawProp draws a prop's texture standing on its position.
+func drawProp(prop sim.Prop) {
+	tex := assets.Texture(prop.Kind)
+	rl.DrawTexture(tex, int32(prop.Position.X)-tex.Width/2, int32(prop.Position.Y)-tex.Height, rl.White)
 }
 
-func drawScene() {
+// cullMargin is how far past the edges of the screen drawScene looks for
+// things to draw. It's more than any sprite reaches from where its thing
+// stands.
+const cullMargin = 256
+
+func drawScene(alpha float32) {
+	player := &world.Player
+
 	// Calculate the visible area based on camera position
-	visibleMinX := int32(camera.Target.X - float32(screenWidth)/2/camera.Zoom)
-	visibleMinY := int32(camera.Target.Y - float32(screenHeight)/2/camera.Zoom)
-	visibleMaxX := int32(camera.Target.X + float32(screenWidth)/2/camera.Zoom)
-	visibleMaxY := int32(camera.Target.Y + float32(screenHeight)/2/camera.Zoom)
-
-	// Draw the ground tiles only in visible area
-	tileWidth := float32(groundSprite.Width)
-	tileHeight := float32(groundSprite.Height)
-
-	startX := (visibleMinX / int32(tileWidth)) * int32(tileWidth)
-	startY := (visibleMinY / int32(tileHeight)) * int32(tileHeight)
-	endX := visibleMaxX + int32(tileWidth)
-	endY := visibleMaxY + int32(tileHeight)
-
-	for y := float32(startY); float32(y) < float32(endY); y += tileHeight {
-		for x := float32(startX); float32(x) < float32(endX); x += tileWidth {
-			rl.DrawTexture(groundSprite, int32(x), int32(y), rl.White)
-		}
-	}
+	visibleMinX := camera.Target.X - float32(screenWidth)/2/camera.Zoom
+	visibleMinY := camera.Target.Y - float32(screenHeight)/2/camera.Zoom
+	visibleMaxX := camera.Target.X + float32(screenWidth)/2/camera.Zoom
+	visibleMaxY := camera.Target.Y + float32(screenHeight)/2/camera.Zoom
 
-	stoneTileSize := float32(stoneTileSprite.Width)
-	rl.DrawTexture(stoneTileSprite, 100, 100, rl.White)
-	rl.DrawTexture(stoneTileSprite, int32(100+stoneTileSize), 100, rl.White)
-	rl.DrawTexture(stoneTileSprite, 100, int32(100+stoneTileSize), rl.White)
+	// Draw the map only in the visible area
+	drawWorldMap(visibleMinX, visibleMinY, visibleMaxX, visibleMaxY)
 
-	rl.DrawTexture(nestSprite, 300, 200, rl.White)
+	for _, prop := range world.Props {
+		drawProp(prop)
+	}
 
 	creatureX := screenWidth - float32(creatureSprite.Width) - 20 // 20 pixels padding from right
 	creatureY := 20                                               // 20 pixels padding from top
 	rl.DrawTexture(creatureSprite, int32(creatureX), int32(creatureY), rl.White)
 
-	for _, pos := range droppedPineCones {
-		rl.DrawTexture(pineConeSprite, int32(pos.X)-pineConeSprite.Width/2, int32(pos.Y)-pineConeSprite.Height/2, rl.White)
-		rl.DrawCircle(int32(pos.X), int32(pos.Y), 5, rl.Blue) // Debug: cone center
+	// Only things standing near the screen are drawn. Sprites reach up and
+	// out from where things stand, so look a little past its edges.
+	view := sim.Rect{
+		X:      visibleMinX - cullMargin,
+		Y:      visibleMinY - cullMargin,
+		Width:  visibleMaxX - visibleMinX + 2*cullMargin,
+		Height: visibleMaxY - visibleMinY + 2*cullMargin,
+	}
+	for _, i := range world.ItemsIn(view) {
+		drawWorldItem(&world.WorldItems[i], alpha)
 	}
 
-	// Draw all trees (growing and fully grown)
-	for _, tree := range growingTrees {
-		treeHeight := float32(pineTreeSprite.Height)
-		treeWidth := float32(pineTreeSprite.Width) / 4 // Assuming 4 frames in sprite sheet
+	// Draw the trees on screen (growing and fully grown)
+	for _, i := range world.TreesIn(view) {
+		tree := world.Trees[i]
+		if tree.Stump {
+			drawStump(tree)
+			continue
+		}
+
+		frame := assets.Frame("pineTree", int(tree.Stage))
+		treeHeight := frame.Height
+		treeWidth := frame.Width
 
-		// Calculate how much of the tree to show based on growth frame
-		growthProgress := float32(tree.frame+1) / 4.0 // Will go from 0.25 to 1.0
+		// Calculate how much of the tree to show based on growth stage
+		growthProgress := float32(tree.Stage+1) / float32(sim.TreeStages) // Will go from 0.25 to 1.0
 		visibleHeight := treeHeight * growthProgress
 
 		// Source rectangle (full width of one frame, but growing in height from bottom)
 		treeSrc := rl.NewRectangle(
-			float32(tree.frame)*treeWidth, // X position in sprite sheet
-			treeHeight-visibleHeight,      // Start from bottom
-			treeWidth,                     // Full width of one frame
-			visibleHeight,                 // Only show the growing portion
+			frame.X,                          // X position in sprite sheet
+			frame.Y+treeHeight-visibleHeight, // Start from bottom
+			treeWidth,                        // Full width of one frame
+			visibleHeight,                    // Only show the growing portion
 		)
 
 		// Destination rectangle (grows upward from the ground position)
 		treeDest := rl.NewRectangle(
-			tree.position.X-treeWidth/2,   // Center horizontally
-			tree.position.Y-visibleHeight, // Position from bottom
-			treeWidth,                     // Same width as source
-			visibleHeight,                 // Same height as visible portion
+			tree.Position.X-treeWidth/2+shakeOffset(tree), // Center horizontally
+			tree.Position.Y-visibleHeight,                 // Position from bottom
+			treeWidth,                                     // Same width as source
+			visibleHeight,                                 // Same height as visible portion
 		)
 
 		rl.DrawTexturePro(pineTreeSprite, treeSrc, treeDest, rl.Vector2{}, 0, rl.White)
 	}
 
-	rl.DrawTexturePro(playerSprite, playerSrc, playerDest, rl.NewVector2(playerDest.Width, playerDest.Height), 0, rl.White)
-	rl.DrawCircle(int32(playerDest.X+playerDest.Width/2), int32(playerDest.Y+playerDest.Height/2), 5, rl.Red) // Debug: player center
-
-	// Draw dropped crystal stones with scaling
-	for _, pos := range droppedCrystalStones {
-		// Calculate scaled dimensions (50% of original size)
-		scaleFactor := float32(0.075)
-		scaledWidth := float32(crystalStoneSprite.Width) * scaleFactor
-		scaledHeight := float32(crystalStoneSprite.Height) * scaleFactor
-
-		// Create source and destination rectangles for scaled drawing
-		src := rl.NewRectangle(0, 0, float32(crystalStoneSprite.Width), float32(crystalStoneSprite.Height))
-		dest := rl.NewRectangle(
-			pos.X-scaledWidth/2,  // Center horizontally
-			pos.Y-scaledHeight/2, // Center vertically
-			scaledWidth,
-			scaledHeight,
-		)
-
-		// Draw the crystal stone with scaling
-		rl.DrawTexturePro(crystalStoneSprite, src, dest, rl.Vector2{}, 0, rl.White)
-	}
+	playerDest := rect(player.InterpolatedDest(alpha))
+	rl.DrawTexturePro(playerSprite, rect(player.Src), playerDest, rl.Vector2{}, 0, rl.White)
+	drawSwing(player, playerDest)
 
 	// Draw particles
-	drawParticles()
+	drawParticles(alpha)
+
+	if debugVisible {
+		drawDebugWorld(alpha, view)
+	}
 
 	// Draw clouds layer 1 (farthest)
-	for _, pos := range cloudsLayer1 {
+	for _, pos := range world.CloudsLayer1 {
 		rl.DrawTexture(cloudSprite, int32(pos.X), int32(pos.Y), rl.Fade(rl.White, 0.5)) // more transparent
 	}
 	// Draw clouds layer 2 (middle)
-	for _, pos := range cloudsLayer2 {
+	for _, pos := range world.CloudsLayer2 {
 		rl.DrawTexture(cloudSprite, int32(pos.X), int32(pos.Y), rl.Fade(rl.White, 0.7))
 	}
 	// Draw clouds layer 3 (closest)
-	for _, pos := range cloudsLayer3 {
+	for _, pos := range world.CloudsLayer3 {
 		rl.DrawTexture(cloudSprite, int32(pos.X), int32(pos.Y), rl.White)
 	}
 }
 
-func input() {
-	if rl.IsKeyDown(rl.KeyW) || rl.IsKeyDown(rl.KeyUp) {
-		playerDest.Y -= playerSpeed
-		playerMoving = true
-		playerDir = 1
-		playerUp = true
-	}
-	if rl.IsKeyDown(rl.KeyS) || rl.IsKeyDown(rl.KeyDown) {
-		playerMoving = true
-		playerDest.Y += playerSpeed
-		playerDir = 0
-		playerDown = true
-	}
-	if rl.IsKeyDown(rl.KeyA) || rl.IsKeyDown(rl.KeyLeft) {
-		playerMoving = true
-		playerDest.X -= playerSpeed
-		playerDir = 2
-		playerLeft = true
-	}
-	if rl.IsKeyDown(rl.KeyD) || rl.IsKeyDown(rl.KeyRight) {
-		playerMoving = true
-		playerDest.X += playerSpeed
-		playerDir = 3
-		playerRight = true
-	}
-
-	if rl.IsKeyPressed(rl.KeySpace) {
-		dropPineCone()
-		fmt.Println("Pine cone dropped!")
-	}
-
-	if rl.IsKeyPressed(rl.KeyG) {
-		fmt.Println("G key pressed!")
-		if onCone, conePos := isPlayerOnPineCone(); onCone {
-			fmt.Println("Standing on pine cone! Starting tree growth at:", conePos)
-			growingTrees = append(growingTrees, struct {
-				position rl.Vector2
-				frame    int
-				growing  bool
-			}{
-				position: conePos,
-				frame:    0,
-				growing:  true,
-			})
-		} else {
-			fmt.Println("Not standing on any pine cone")
-		}
-	}
-
-	if rl.IsKeyPressed(rl.KeyV) {
-		fmt.Println("V key pressed!")
-		pickUpPineCone()
-	}
-
-	if rl.IsKeyPressed(rl.KeyB) { // Use B key to drop crystal stone
-		dropCrystalStone()
-	}
-
-	if rl.IsKeyPressed(rl.KeyN) { // Use N key to pick up crystal stone
-		pickUpCrystalStone()
-	}
-
-	if rl.IsKeyPressed(rl.KeyP) { // Use P key to trigger splash
-		// Create splash at player position
-		playerCenter := rl.Vector2{
-			X: playerDest.X + playerDest.Width/2,
-			Y: playerDest.Y + playerDest.Height/2,
-		}
-		createSplashEffect(playerCenter.X, playerCenter.Y)
-	}
-}
-
+// update runs as many fixed-size simulation steps as the frame time allows.
+// Whatever is left over stays in the accumulator for the next frame.
 func update() {
 	running = !rl.WindowShouldClose()
 
-	if playerMoving {
-		if playerUp {
-			playerDest.Y -= playerSpeed
-		}
-		if playerDown {
-			playerDest.Y += playerSpeed
+	in := input()
+	updateConsole()
+	if consoleOpen {
+		// Typing in the console mustn't also walk the player around.
+		in = sim.Inputs{}
+	} else {
+		saveInput()
+		if bindings.Pressed(ActionToggleFullscreen) {
+			toggleFullscreen()
 		}
-		if playerRight {
-			playerDest.X += playerSpeed
-		}
-		if playerLeft {
-			playerDest.X -= playerSpeed
-		}
-
-		if frameCount%8 == 1 {
-			playerFrame++
+		if bindings.Pressed(ActionToggleDebug) {
+			debugVisible = !debugVisible
 		}
 	}
-	frameCount++
-	if playerFrame > 3 {
-		playerFrame = 0
+	pendingInputs = pendingInputs.Latch(in)
+
+	rememberWindowSize()
+	recordFrameTime(rl.GetFrameTime())
+
+	accumulator += min(rl.GetFrameTime(), maxFrameTime)
+	for accumulator >= fixedDt {
+		stepWorld(pendingInputs)
+		pendingInputs = pendingInputs.Held()
+		accumulator -= fixedDt
 	}
 
-	playerSrc.X = playerSrc.Width * float32(playerFrame)
-	playerSrc.Y = playerSrc.Height * float32(playerDir)
+	autosave()
+}
 
-	// Update camera to follow player
+// updateCamera follows the player's interpolated position.
+func updateCamera(alpha float32) {
+	playerDest := world.Player.InterpolatedDest(alpha)
 	camera.Target = rl.Vector2{
 		X: playerDest.X + playerDest.Width/2,
 		Y: playerDest.Y + playerDest.Height/2,
 	}
-
-	// Smooth camera following
-	const smoothness float32 = 0.1
-	camera.Target.X = camera.Target.X + (playerDest.X+playerDest.Width/2-camera.Target.X)*smoothness
-	camera.Target.Y = camera.Target.Y + (playerDest.Y+playerDest.Height/2-camera.Target.Y)*smoothness
-
-	updateTrees()
-	updateParticles()
-	updateClouds()
 }
 
-func render() {
-	rl.BeginDrawing()
+// render draws the world alpha of the way between the last two simulation
+// steps, so movement stays smooth when the frame rate and tick rate differ.
+func render(alpha float32) {
+	updateCamera(alpha)
+
+	rl.BeginTextureMode(target)
 
 	rl.ClearBackground(bkgColor)
 
 	// Begin camera mode before drawing scene
 	rl.BeginMode2D(camera)
 
-	playerMoving = false
-	playerUp, playerDown, playerRight, playerLeft = false, false, false, false
-
-	drawScene()
+	drawScene(alpha)
 
 	rl.EndMode2D() // End camera mode
 
-	// Draw the backpack background at the bottom center of the screen
-	bagX := 100                                                       // distance from the left side
-	bagY := float32(screenHeight) - float32(bagBgSprite.Height) - 420 // higher up
-
-	// Apply scaling factor to make the bag smaller (0.7 = 70% of original size)
-	scaleFactor := float32(0.7)
-	scaledBagWidth := float32(bagBgSprite.Width) * scaleFactor
-	scaledBagHeight := float32(bagBgSprite.Height) * scaleFactor
-
-	// Create source and destination rectangles for scaled drawing
+	// Draw the backpack in the bottom-left corner of the virtual screen
+	bagWidth := float32(bagBgSprite.Width) * bagScale
+	bagHeight := float32(bagBgSprite.Height) * bagScale
 	bagSrc := rl.NewRectangle(0, 0, float32(bagBgSprite.Width), float32(bagBgSprite.Height))
-	bagDest := rl.NewRectangle(float32(bagX), float32(bagY), scaledBagWidth, scaledBagHeight)
+	bagDest := rl.NewRectangle(bagMargin, screenHeight-bagMargin-bagHeight, bagWidth, bagHeight)
 
 	// Draw the bag with scaling
 	rl.DrawTexturePro(bagBgSprite, bagSrc, bagDest, rl.Vector2{}, 0, rl.White)
 
-	// Draw the inventory slots and items - adjust for new scale
-	slotSize := scaledBagWidth / 4
-	for i, slot := range inventory {
-		// Adjust item positions according to the new scale
-		slotX := int32(bagX) + int32(float32(i)*slotSize) + int32(slotSize/3) - int32(float32(pineConeIconSprite.Width)*scaleFactor/3)
-		slotY := int32(bagY) + int32(scaledBagHeight/2) - int32(float32(pineConeIconSprite.Height)*scaleFactor/4)
-
-		if slot.Item == ItemPineCone && slot.Count > 0 {
-			// Draw the pinecone icon with the same scale factor
-			iconSrc := rl.NewRectangle(0, 0, float32(pineConeIconSprite.Width), float32(pineConeIconSprite.Height))
-			iconDest := rl.NewRectangle(float32(slotX), float32(slotY),
-				float32(pineConeIconSprite.Width)*scaleFactor,
-				float32(pineConeIconSprite.Height)*scaleFactor)
-
-			rl.DrawTexturePro(pineConeIconSprite, iconSrc, iconDest, rl.Vector2{}, 0, rl.White)
-
-			// Adjust text position and size
-			textSize := int32(20 * scaleFactor)
-			textX := slotX + int32(32*scaleFactor)
-			textY := slotY + int32(32*scaleFactor)
-			rl.DrawText(fmt.Sprintf("%d", slot.Count), textX, textY, textSize, rl.Black)
-		}
-		// Add more item types here as you add them
-	}
-
-	rl.DrawText(fmt.Sprintf("Pine Cones: %d", pineConeCount), 20, 20, 30, rl.Black)
-
-	rl.EndDrawing()
-}
-
-func init() {
-	rl.InitWindow(screenWidth, screenHeight, "Totoro")
-	rl.SetExitKey(0)
-	rl.SetTargetFPS(60)
-
-	groundSprite = rl.LoadTexture("res/Tilesets/ground.png")
-	playerSprite = rl.LoadTexture("res/Characters/Basic Charakter Spritesheet.png")
-	nestSprite = rl.LoadTexture("res/Tilesets/nest.png")
-	creatureSprite = rl.LoadTexture("res/Tilesets/creature.png")
-	stoneTileSprite = rl.LoadTexture("res/Tilesets/stone_tiles.png")
-	pineConeSprite = rl.LoadTexture("res/Objects/pine_cone.png")
-
-	playerSrc = rl.NewRectangle(0, 0, 48, 48)
-	playerDest = rl.NewRectangle(200, 200, 100, 100)
-
-	droppedPineCones = make([]rl.Vector2, 0)
-	pineConeCount = 5 // Start with 5 pinecones in inventory
-
-	pineTreeSprite = rl.LoadTexture("res/Objects/pine_tree_growth.png") // Make sure to add this sprite
-	growingTrees = make([]struct {
-		position rl.Vector2
-		frame    int
-		growing  bool
-	}, 0)
-
-	// Initialize camera
-	camera = rl.Camera2D{
-		Target:   rl.Vector2{X: playerDest.X + playerDest.Width/2, Y: playerDest.Y + playerDest.Height/2},
-		Offset:   rl.Vector2{X: float32(screenWidth) / 2, Y: float32(screenHeight) / 2},
-		Rotation: 0,
-		Zoom:     1.0,
+	// Draw the inventory slots and items
+	for i, slot := range world.Inventory.Slots {
+		drawInventorySlot(slot, inventorySlotRect(bagDest, i), i == world.Inventory.Selected)
 	}
 
-	bagBgSprite = rl.LoadTexture("res/UI/bag_bg.png")
-	pineConeIconSprite = rl.LoadTexture("res/UI/pinecone_icon.png")
-
-	// Initialize inventory
-	for i := range inventory {
-		inventory[i].Item = ItemNone
-		inventory[i].Count = 0
-	}
-
-	// Set initial inventory
-	updateInventory()
-
-	crystalStoneSprite = rl.LoadTexture("res/Objects/crystal_stone.png")
-	crystalStoneCount = 5 // Start with 5 crystal stones in inventory
-	droppedCrystalStones = make([]rl.Vector2, 0)
+	drawStatus()
 
-	// Update inventory to show initial crystal stones
-	updateInventory()
-
-	particles = make([]Particle, 0)
-	rand.Seed(time.Now().UnixNano()) // Initialize random seed
-
-	cloudSprite = rl.LoadTexture("res/Objects/cloud.png")
-	initClouds()
-}
-
-func quit() {
-	rl.CloseWindow()
-	rl.UnloadTexture(groundSprite)
-	rl.UnloadTexture(playerSprite)
-	rl.UnloadTexture(nestSprite)
-	rl.UnloadTexture(creatureSprite)
-	rl.UnloadTexture(stoneTileSprite)
-	rl.UnloadTexture(pineConeSprite)
-	rl.UnloadTexture(pineTreeSprite)
-	rl.UnloadTexture(bagBgSprite)
-	rl.UnloadTexture(pineConeIconSprite)
-	rl.UnloadTexture(crystalStoneSprite)
-	rl.UnloadTexture(cloudSprite)
-}
-
-func updateInventory() {
-	// Clear inventory first
-	for i := range inventory {
-		inventory[i].Item = ItemNone
-		inventory[i].Count = 0
+	if debugVisible {
+		drawDebugOverlay()
 	}
+	drawConsole()
 
-	// Add pine cones to first slot
-	if pineConeCount > 0 {
-		inventory[0].Item = ItemPineCone
-		inventory[0].Count = pineConeCount
-	}
+	rl.EndTextureMode()
 
-	// Add crystal stones to second slot
-	if crystalStoneCount > 0 {
-		inventory[1].Item = ItemCrystalStone
-		inventory[1].Count = crystalStoneCount
-	}
+	presentFrame()
 }
 
-func dropPineCone() {
-	// Only drop if we have pinecones in inventory
-	if pineConeCount <= 0 {
-		fmt.Println("No pine cones to drop!")
-		return
+func setup() {
+	var err error
+	resources, err = newResources(*resDir)
+	if err != nil {
+		assetsLog.Warn("resource override ignored", "err", err)
+		resources, _ = newResources("")
 	}
 
-	// Get player center
-	playerCenter := rl.Vector2{
-		X: playerDest.X + playerDest.Width/2,
-		Y: playerDest.Y + playerDest.Height/2,
-	}
-
-	// Drop offset based on the direction the player is facing
-	var offsetX, offsetY float32
-
-	// Use playerDir to determine drop position
-	switch playerDir {
-	case 0: // Down
-		offsetX = 0
-		offsetY = playerDest.Height/2 + 30 // Drop in front of player
-	case 1: // Up
-		offsetX = 0
-		offsetY = -playerDest.Height/2 - 30 // Drop above player
-	case 2: // Left
-		offsetX = -playerDest.Width/2 - 30 // Drop to left of player
-		offsetY = 0
-	case 3: // Right
-		offsetX = playerDest.Width/2 + 30 // Drop to right of player
-		offsetY = 0
-	}
-
-	// Calculate the dropping position
-	pineConePos := rl.Vector2{
-		X: playerCenter.X + offsetX,
-		Y: playerCenter.Y + offsetY,
-	}
-
-	droppedPineCones = append(droppedPineCones, pineConePos)
-	pineConeCount-- // Decrease inventory count
-
-	// Update the inventory UI
-	updateInventory()
-
-	fmt.Printf("Dropped pine cone at: %v (facing direction: %d)\n", pineConePos, playerDir)
-}
-
-func isPlayerOnPineCone() (bool, rl.Vector2) {
-	playerCenter := rl.Vector2{
-		X: playerDest.X + playerDest.Width/2,
-		Y: playerDest.Y + playerDest.Height/2,
+	var errs []error
+	bindings, errs = loadBindings(bindingsPath())
+	for _, err := range errs {
+		inputLog.Warn("bindings", "err", err)
 	}
 
-	for i, cone := range droppedPineCones {
-		// Calculate distance between player and pine cone
-		distance := float32(
-			math.Sqrt(
-				float64(
-					(playerCenter.X-cone.X)*(playerCenter.X-cone.X) +
-						(playerCenter.Y-cone.Y)*(playerCenter.Y-cone.Y),
-				),
-			),
-		)
-
-		fmt.Printf("Distance to cone: %f\n", distance)
-
-		// Increased interaction radius to 150 pixels
-		if distance < 150 {
-			fmt.Println("Successfully interacted with pine cone!")
-			droppedPineCones = append(droppedPineCones[:i], droppedPineCones[i+1:]...)
-			return true, cone
-		}
+	settings, err = loadSettings(settingsPath())
+	if err != nil {
+		displayLog.Warn("settings", "err", err)
 	}
-	return false, rl.Vector2{}
-}
 
-func updateTrees() {
-	for i := range growingTrees {
-		if growingTrees[i].growing {
-			if frameCount%treeAnimationSpeed == 0 {
-				growingTrees[i].frame++
-				fmt.Printf("Tree %d animation frame: %d\n", i, growingTrees[i].frame)
-				if growingTrees[i].frame >= 4 {
-					growingTrees[i].growing = false
-					growingTrees[i].frame = 3 // Keep final frame
-					fmt.Printf("Tree %d finished growing\n", i)
-				}
-			}
-		}
-	}
-}
+	openWindow()
 
-func drawDebug() {
-	// Draw player center point
-	playerCenter := rl.Vector2{
-		X: playerDest.X + playerDest.Width/2,
-		Y: playerDest.Y + playerDest.Height/2,
+	assets, errs = loadAssets(resources, manifestPath)
+	for _, err := range errs {
+		assetsLog.Error("loading assets", "err", err)
 	}
-	rl.DrawCircle(int32(playerCenter.X), int32(playerCenter.Y), 3, rl.Red)
 
-	// Draw interaction radius around pine cones
-	for _, cone := range droppedPineCones {
-		rl.DrawCircleLines(int32(cone.X), int32(cone.Y), 150, rl.Green)
+	items, err = sim.LoadItems(resources)
+	if err != nil {
+		assetsLog.Error("loading items", "err", err)
+		items = &sim.ItemRegistry{}
 	}
-
-	// Draw interaction radius around crystal stones
-	for _, stone := range droppedCrystalStones {
-		rl.DrawCircleLines(int32(stone.X), int32(stone.Y), 150, rl.Purple)
+	for _, err := range checkItemSprites(items, assets) {
+		assetsLog.Error("item definitions", "err", err)
 	}
-}
-
-func pickUpPineCone() {
-	playerCenter := rl.Vector2{
-		X: playerDest.X + playerDest.Width/2,
-		Y: playerDest.Y + playerDest.Height/2,
+	for _, err := range loadWorldMap() {
+		assetsLog.Error("loading map", "err", err)
 	}
 
-	// Log the player position for debugging
-	fmt.Printf("Player center position: %v\n", playerCenter)
-
-	for i, cone := range droppedPineCones {
-		// Log each cone position
-		fmt.Printf("Checking cone at position: %v\n", cone)
-
-		distance := float32(math.Hypot(float64(playerCenter.X-cone.X), float64(playerCenter.Y-cone.Y)))
-		fmt.Printf("Distance to cone: %f\n", distance)
+	playerSprite = assets.Texture("player")
+	creatureSprite = assets.Texture("creature")
+	pineTreeSprite = assets.Texture("pineTree")
 
-		// Increased pickup radius to match the interaction radius from isPlayerOnPineCone
-		if distance < 150 {
-			fmt.Println("Pine cone picked up!")
-			droppedPineCones = append(droppedPineCones[:i], droppedPineCones[i+1:]...)
-			pineConeCount++
+	bagBgSprite = assets.Texture("bagBg")
 
-			// Update the inventory UI
-			updateInventory()
+	cloudSprite = assets.Texture("cloud")
 
-			return // Added return to prevent checking other cones after picking one up
+	if *replayPath != "" {
+		if err := startReplay(); err != nil {
+			replayLog.Error("can't replay", "err", err)
+			*replayPath = ""
 		}
 	}
-	fmt.Println("No pine cone in range to pick up")
-}
-
-func dropCrystalStone() {
-	if crystalStoneCount <= 0 {
-		fmt.Println("No crystal stones to drop!")
-		return
+	if playback == nil {
+		world = sim.NewWorld(sim.Config{
+			Seed:       worldSeed(),
+			ViewWidth:  screenWidth,
+			CloudWidth: float32(cloudSprite.Width),
+			Items:      items,
+			MapPath:    *mapPath,
+			Map:        worldMap,
+		})
 	}
 
-	playerCenter := rl.Vector2{
-		X: playerDest.X + playerDest.Width/2,
-		Y: playerDest.Y + playerDest.Height/2,
-	}
-
-	var offsetX, offsetY float32
-	switch playerDir {
-	case 0: // Down
-		offsetX = 0
-		offsetY = playerDest.Height/2 + 30
-	case 1: // Up
-		offsetX = 0
-		offsetY = -playerDest.Height/2 - 30
-	case 2: // Left
-		offsetX = -playerDest.Width/2 - 30
-		offsetY = 0
-	case 3: // Right
-		offsetX = playerDest.Width/2 + 30
-		offsetY = 0
+	// A replay's world must not be resumed over or autosaved, since it isn't
+	// the player's game.
+	if playback == nil {
+		startAutosave()
+		startRecording()
 	}
 
-	crystalStonePos := rl.Vector2{
-		X: playerCenter.X + offsetX,
-		Y: playerCenter.Y + offsetY,
+	// Initialize camera
+	playerCenter := world.Player.Center()
+	camera = rl.Camera2D{
+		Target:   rl.Vector2{X: playerCenter.X, Y: playerCenter.Y},
+		Offset:   rl.Vector2{X: float32(screenWidth) / 2, Y: float32(screenHeight) / 2},
+		Rotation: 0,
+		Zoom:     1.0,
 	}
-
-	droppedCrystalStones = append(droppedCrystalStones, crystalStonePos)
-	crystalStoneCount--
-
-	updateInventory()
-	fmt.Printf("Dropped crystal stone at: %v (facing direction: %d)\n", crystalStonePos, playerDir)
 }
 
-func pickUpCrystalStone() {
-	playerCenter := rl.Vector2{
-		X: playerDest.X + playerDest.Width/2,
-		Y: playerDest.Y + playerDest.Height/2,
-	}
-
-	// Log the player position for debugging
-	fmt.Printf("Player center position: %v\n", playerCenter)
-
-	for i, stone := range droppedCrystalStones {
-		// Log each stone position
-		fmt.Printf("Checking crystal stone at position: %v\n", stone)
-
-		// Calculate distance between player and crystal stone
-		distance := float32(math.Hypot(float64(playerCenter.X-stone.X), float64(playerCenter.Y-stone.Y)))
-		fmt.Printf("Distance to crystal stone: %f\n", distance)
-
-		// Increased pickup radius to match the interaction radius (150 pixels)
-		if distance < 150 {
-			fmt.Println("Crystal stone picked up!")
-			droppedCrystalStones = append(droppedCrystalStones[:i], droppedCrystalStones[i+1:]...)
-			crystalStoneCount++
-			updateInventory()
-			return // Added return to prevent checking other stones after picking one up
-		}
-	}
-	fmt.Println("No crystal stone in range to pick up")
-}
+func quit() {
+	stopRecording()
+	stopAutosave()
+	assets.Unload()
+	closeWindow()
 
-func createSplashEffect(x, y float32) {
-	numParticles := 20 // Number of particles in the splash
-	for i := 0; i < numParticles; i++ {
-		// Random angle for particle direction
-		angle := float32(rand.Float64() * math.Pi * 2)
-		// Random speed between 2 and 5
-		speed := float32(2 + rand.Float64()*3)
-
-		particle := Particle{
-			position: rl.Vector2{X: x, Y: y},
-			velocity: rl.Vector2{
-				X: float32(math.Cos(float64(angle))) * speed,
-				Y: float32(math.Sin(float64(angle))) * speed,
-			},
-			color:   rl.NewColor(100, 200, 255, 255), // Light blue color
-			size:    float32(2 + rand.Float64()*3),   // Random size between 2 and 5
-			life:    1.0,                             // Full life
-			maxLife: 1.0,                             // Maximum life
-		}
-		particles = append(particles, particle)
+	if err := saveSettings(settingsPath(), settings); err != nil {
+		displayLog.Error("saving settings", "err", err)
 	}
+	closeLogging()
 }
 
-func updateParticles() {
-	for i := len(particles) - 1; i >= 0; i-- {
-		// Update position
-		particles[i].position.X += particles[i].velocity.X
-		particles[i].position.Y += particles[i].velocity.Y
-
-		// Apply gravity
-		particles[i].velocity.Y += 0.1
-
-		// Reduce life
-		particles[i].life -= 0.02
-
-		// Remove dead particles
-		if particles[i].life <= 0 {
-			particles = append(particles[:i], particles[i+1:]...)
-		}
-	}
-}
+func drawParticles(alpha float32) {
+	for _, p := range world.Particles {
+		pos := sim.Lerp(p.PrevPosition, p.Position, alpha)
 
-func drawParticles() {
-	for _, p := range particles {
-		// Calculate alpha based on life
-		alpha := uint8(p.life * 255)
-		color := rl.NewColor(p.color.R, p.color.G, p.color.B, alpha)
+		// Fade out based on life
+		c := color(p.Color)
+		c.A = uint8(p.Life * 255)
 
 		// Draw particle as a circle
 		rl.DrawCircle(
-			int32(p.position.X),
-			int32(p.position.Y),
-			p.size, // Changed from int32(p.size) to just p.size (already float32)
-			color,
+			int32(pos.X),
+			int32(pos.Y),
+			p.Size,
+			c,
 		)
 	}
 }
 
-func initClouds() {
-	// Layer 1: Farthest, fewest clouds, highest Y, slowest
-	cloudsLayer1 = []rl.Vector2{
-		{X: 100, Y: 80},
-		{X: 700, Y: 120},
-		{X: 1400, Y: 60},
-	}
-	// Layer 2: Middle
-	cloudsLayer2 = []rl.Vector2{
-		{X: 300, Y: 200},
-		{X: 900, Y: 180},
-		{X: 1600, Y: 220},
-	}
-	// Layer 3: Closest, lowest Y, fastest
-	cloudsLayer3 = []rl.Vector2{
-		{X: 50, Y: 320},
-		{X: 800, Y: 350},
-		{X: 1500, Y: 300},
-	}
+var (
+	resDir = flag.String("res-dir", "", "directory whose files override the embedded res/ tree")
+	seed   = flag.Int64("seed", 0, "start a new world from this seed instead of resuming the autosave")
+)
+
+// seedGiven reports whether -seed was on the command line, since 0 is a
+// valid seed too.
+func seedGiven() bool {
+	given := false
+	flag.Visit(func(f *flag.Flag) {
+		given = given || f.Name == "seed"
+	})
+	return given
 }
 
-func updateClouds() {
-	// Layer 1: slowest
-	for i := range cloudsLayer1 {
-		cloudsLayer1[i].X += 0.2
-		if cloudsLayer1[i].X > float32(screenWidth) {
-			cloudsLayer1[i].X = -float32(cloudSprite.Width)
-		}
-	}
-	// Layer 2: medium
-	for i := range cloudsLayer2 {
-		cloudsLayer2[i].X += 0.5
-		if cloudsLayer2[i].X > float32(screenWidth) {
-			cloudsLayer2[i].X = -float32(cloudSprite.Width)
-		}
-	}
-	// Layer 3: fastest
-	for i := range cloudsLayer3 {
-		cloudsLayer3[i].X += 1.0
-		if cloudsLayer3[i].X > float32(screenWidth) {
-			cloudsLayer3[i].X = -float32(cloudSprite.Width)
-		}
+// worldSeed is the -seed flag if given, or else a fresh random seed. Either
+// way it's printed so an interesting world can be made again.
+func worldSeed() int64 {
+	s := *seed
+	if !seedGiven() {
+		s = time.Now().UnixNano()
 	}
+	worldLog.Info("new world", "seed", s)
+	return s
 }
 
 func main() {
+	flag.Parse()
+	setupLogging()
+	setup()
+	registerCommands()
+
 	for running {
-		input()
 		update()
-		render()
+		render(accumulator / fixedDt)
 	}
 	quit()
 }
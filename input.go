@@ -0,0 +1,376 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"main/sim"
+)
+
+// Action is something the player can do, independent of which key, mouse
+// button or gamepad control triggers it.
+type Action int
+
+const (
+	ActionMoveUp Action = iota
+	ActionMoveDown
+	ActionMoveLeft
+	ActionMoveRight
+	ActionDrop
+	ActionUse
+	ActionPlant
+	ActionInteract
+	ActionSplash
+	ActionSelectSlot1
+	ActionSelectSlot2
+	ActionSelectSlot3
+	ActionSelectSlot4
+	ActionNextSlot
+	ActionPrevSlot
+	ActionQuickSave
+	ActionQuickLoad
+	ActionSaveSlot1
+	ActionSaveSlot2
+	ActionSaveSlot3
+	ActionSaveSlot4
+	ActionLoadSlot1
+	ActionLoadSlot2
+	ActionLoadSlot3
+	ActionLoadSlot4
+	ActionToggleFullscreen
+	ActionToggleDebug
+	ActionToggleConsole
+
+	actionCount
+)
+
+var actionNames = [actionCount]string{
+	ActionMoveUp:           "MoveUp",
+	ActionMoveDown:         "MoveDown",
+	ActionMoveLeft:         "MoveLeft",
+	ActionMoveRight:        "MoveRight",
+	ActionDrop:             "Drop",
+	ActionUse:              "Use",
+	ActionPlant:            "Plant",
+	ActionInteract:         "Interact",
+	ActionSplash:           "Splash",
+	ActionSelectSlot1:      "SelectSlot1",
+	ActionSelectSlot2:      "SelectSlot2",
+	ActionSelectSlot3:      "SelectSlot3",
+	ActionSelectSlot4:      "SelectSlot4",
+	ActionNextSlot:         "NextSlot",
+	ActionPrevSlot:         "PrevSlot",
+	ActionQuickSave:        "QuickSave",
+	ActionQuickLoad:        "QuickLoad",
+	ActionSaveSlot1:        "SaveSlot1",
+	ActionSaveSlot2:        "SaveSlot2",
+	ActionSaveSlot3:        "SaveSlot3",
+	ActionSaveSlot4:        "SaveSlot4",
+	ActionLoadSlot1:        "LoadSlot1",
+	ActionLoadSlot2:        "LoadSlot2",
+	ActionLoadSlot3:        "LoadSlot3",
+	ActionLoadSlot4:        "LoadSlot4",
+	ActionToggleFullscreen: "ToggleFullscreen",
+	ActionToggleDebug:      "ToggleDebug",
+	ActionToggleConsole:    "ToggleConsole",
+}
+
+// Every action needs a name, or it can't be written to or read from a
+// bindings file.
+func init() {
+	for a, name := range actionNames {
+		if name == "" {
+			panic(fmt.Sprintf("action %d has no name", a))
+		}
+	}
+}
+
+func (a Action) String() string {
+	if a < 0 || a >= actionCount {
+		return fmt.Sprintf("Action(%d)", int(a))
+	}
+	return actionNames[a]
+}
+
+// ParseAction looks an action up by name, ignoring case.
+func ParseAction(name string) (Action, error) {
+	for a, n := range actionNames {
+		if strings.EqualFold(n, name) {
+			return Action(a), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown action %q", name)
+}
+
+// defaultBindings is the control scheme used when there is no bindings file,
+// and for any action the file leaves out.
+var defaultBindings = map[Action][]string{
+	ActionMoveUp:           {"key:W", "key:Up", "button:DpadUp", "axis:LeftY-"},
+	ActionMoveDown:         {"key:S", "key:Down", "button:DpadDown", "axis:LeftY+"},
+	ActionMoveLeft:         {"key:A", "key:Left", "button:DpadLeft", "axis:LeftX-"},
+	ActionMoveRight:        {"key:D", "key:Right", "button:DpadRight", "axis:LeftX+"},
+	ActionDrop:             {"key:Space", "button:A"},
+	ActionUse:              {"key:F", "mouse:Left", "button:RB"},
+	ActionPlant:            {"key:G", "button:Y"},
+	ActionInteract:         {"key:E", "key:V", "button:X"},
+	ActionSplash:           {"key:P", "button:B"},
+	ActionSelectSlot1:      {"key:1"},
+	ActionSelectSlot2:      {"key:2"},
+	ActionSelectSlot3:      {"key:3"},
+	ActionSelectSlot4:      {"key:4"},
+	ActionNextSlot:         {"mouse:WheelDown", "button:RT"},
+	ActionPrevSlot:         {"mouse:WheelUp", "button:LT"},
+	ActionQuickSave:        {"key:F5"},
+	ActionQuickLoad:        {"key:F9"},
+	ActionSaveSlot1:        {"key:Ctrl+1"},
+	ActionSaveSlot2:        {"key:Ctrl+2"},
+	ActionSaveSlot3:        {"key:Ctrl+3"},
+	ActionSaveSlot4:        {"key:Ctrl+4"},
+	ActionLoadSlot1:        {"key:Alt+1"},
+	ActionLoadSlot2:        {"key:Alt+2"},
+	ActionLoadSlot3:        {"key:Alt+3"},
+	ActionLoadSlot4:        {"key:Alt+4"},
+	ActionToggleFullscreen: {"key:F11"},
+	ActionToggleDebug:      {"key:F3"},
+	ActionToggleConsole:    {"key:Grave"},
+}
+
+// renamedActions maps action names from older bindings files to the actions
+// that replaced them, so customized controls carry over. Actions with no
+// replacement map to "" and are skipped.
+var renamedActions = map[string]string{
+	"DropPineCone":       "Drop",
+	"PickUpPineCone":     "Interact",
+	"DropCrystalStone":   "",
+	"PickUpCrystalStone": "",
+}
+
+// Bindings maps every action to the controls that trigger it, and tracks
+// their state from frame to frame.
+type Bindings struct {
+	Gamepad  int32   // Which gamepad to read
+	Deadzone float32 // Stick deflection below this counts as centered
+
+	table [actionCount][]Binding
+	prev  [actionCount]float32
+	cur   [actionCount]float32
+}
+
+// bindingsFile is the on-disk form of Bindings.
+type bindingsFile struct {
+	Gamepad  int32               `json:"gamepad"`
+	Deadzone float32             `json:"deadzone"`
+	Bindings map[string][]string `json:"bindings"`
+}
+
+func bindingsPath() string {
+	return filepath.Join(configDir(), "bindings.json")
+}
+
+// newBindings returns the default control scheme.
+func newBindings() *Bindings {
+	b := &Bindings{Deadzone: 0.25}
+	b.Reset()
+	return b
+}
+
+// Reset restores every action to its default controls.
+func (b *Bindings) Reset() {
+	for a := Action(0); a < actionCount; a++ {
+		b.table[a] = nil
+		for _, s := range defaultBindings[a] {
+			bind, err := ParseBinding(s)
+			if err != nil {
+				panic(err) // The defaults are wrong, which is a bug
+			}
+			b.table[a] = append(b.table[a], bind)
+		}
+	}
+}
+
+// loadBindings reads the bindings file at path. A missing file gets the
+// defaults written to it so players have something to edit. Bad entries are
+// reported and left at their defaults rather than making the game unplayable.
+func loadBindings(path string) (*Bindings, []error) {
+	b := newBindings()
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		if err := b.Save(path); err != nil {
+			return b, []error{err}
+		}
+		return b, nil
+	}
+	if err != nil {
+		return b, []error{err}
+	}
+
+	var f bindingsFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return b, []error{fmt.Errorf("parsing %s: %w", path, err)}
+	}
+
+	b.Gamepad = f.Gamepad
+	if f.Deadzone > 0 && f.Deadzone < 1 {
+		b.Deadzone = f.Deadzone
+	}
+
+	var errs []error
+	for name, binds := range f.Bindings {
+		if newName, ok := renamedActions[name]; ok {
+			if _, both := f.Bindings[newName]; newName == "" || both {
+				continue
+			}
+			name = newName
+		}
+		a, err := ParseAction(name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+
+		var parsed []Binding
+		for _, s := range binds {
+			bind, err := ParseBinding(s)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %s: %w", path, name, err))
+				continue
+			}
+			parsed = append(parsed, bind)
+		}
+		if len(parsed) > 0 || len(binds) == 0 {
+			b.table[a] = parsed
+		}
+	}
+	return b, errs
+}
+
+// Save writes the bindings to path.
+func (b *Bindings) Save(path string) error {
+	f := bindingsFile{
+		Gamepad:  b.Gamepad,
+		Deadzone: b.Deadzone,
+		Bindings: make(map[string][]string),
+	}
+	for a := Action(0); a < actionCount; a++ {
+		binds := make([]string, 0, len(b.table[a]))
+		for _, bind := range b.table[a] {
+			binds = append(binds, bind.String())
+		}
+		f.Bindings[a.String()] = binds
+	}
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Bind adds a control to an action. Binding the same control twice is a
+// no-op.
+func (b *Bindings) Bind(a Action, bind Binding) {
+	if !slices.Contains(b.table[a], bind) {
+		b.table[a] = append(b.table[a], bind)
+	}
+}
+
+// Unbind removes a control from an action.
+func (b *Bindings) Unbind(a Action, bind Binding) {
+	b.table[a] = slices.DeleteFunc(b.table[a], func(other Binding) bool { return other == bind })
+}
+
+// Clear removes every control from an action.
+func (b *Bindings) Clear(a Action) {
+	b.table[a] = nil
+}
+
+// Controls returns the controls bound to an action.
+func (b *Bindings) Controls(a Action) []Binding {
+	return slices.Clone(b.table[a])
+}
+
+// Poll reads every bound control. Call it once per frame, before asking
+// about any action.
+func (b *Bindings) Poll() {
+	b.prev = b.cur
+	for a := Action(0); a < actionCount; a++ {
+		var v float32
+		for _, bind := range b.table[a] {
+			v = max(v, bind.value(b.Gamepad, b.Deadzone))
+		}
+		b.cur[a] = v
+	}
+}
+
+// Value is how strongly an action is held this frame, from 0 to 1. Keys and
+// buttons are all or nothing; sticks give anything in between.
+func (b *Bindings) Value(a Action) float32 {
+	return b.cur[a]
+}
+
+// Down reports whether an action is held this frame.
+func (b *Bindings) Down(a Action) bool {
+	return b.cur[a] >= 0.5
+}
+
+// Pressed reports whether an action started being held this frame.
+func (b *Bindings) Pressed(a Action) bool {
+	return b.cur[a] >= 0.5 && b.prev[a] < 0.5
+}
+
+var bindings = newBindings()
+
+// input polls the bound controls for this frame.
+func input() sim.Inputs {
+	bindings.Poll()
+
+	return sim.Inputs{
+		MoveX: bindings.Value(ActionMoveRight) - bindings.Value(ActionMoveLeft),
+		MoveY: bindings.Value(ActionMoveDown) - bindings.Value(ActionMoveUp),
+
+		Drop:     bindings.Pressed(ActionDrop),
+		Use:      bindings.Pressed(ActionUse),
+		Interact: bindings.Pressed(ActionInteract),
+		Plant:    bindings.Pressed(ActionPlant),
+		Splash:   bindings.Pressed(ActionSplash),
+
+		Select: pressedSlot(),
+		Scroll: pressedInt(ActionNextSlot) - pressedInt(ActionPrevSlot),
+	}
+}
+
+var slotActions = []Action{ActionSelectSlot1, ActionSelectSlot2, ActionSelectSlot3, ActionSelectSlot4}
+
+// pressedSlot returns the inventory slot picked this frame, counting from 1,
+// or 0 if none was. A save or load slot action held at the same time wins,
+// so Ctrl+1 saves to slot 1 without also selecting inventory slot 1.
+func pressedSlot() int {
+	for i := range saveSlotActions {
+		if bindings.Down(saveSlotActions[i]) || bindings.Down(loadSlotActions[i]) {
+			return 0
+		}
+	}
+	for i, a := range slotActions {
+		if bindings.Pressed(a) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func pressedInt(a Action) int {
+	if bindings.Pressed(a) {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+
+	"main/sim"
+)
+
+// checkItemSprites reports item definitions that name textures missing from
+// the asset manifest.
+func checkItemSprites(items *sim.ItemRegistry, assets *Assets) []error {
+	var errs []error
+	for _, def := range items.All() {
+		for _, name := range []string{def.Sprite, def.Icon} {
+			if !assets.Has(name) {
+				errs = append(errs, fmt.Errorf("item %q uses texture %q, which is not in the manifest", def.ID, name))
+			}
+		}
+	}
+	return errs
+}
+
+// itemShadowColor is the shadow under items, which stays on the ground while
+// a tossed item flies.
+var itemShadowColor = rl.NewColor(0, 0, 0, 60)
+
+// drawWorldItem draws an item lying in the world, centered on its position
+// and scaled as its definition says, with the count beside stacks. alpha is
+// how far the renderer is between the last two steps.
+func drawWorldItem(item *sim.WorldItem, alpha float32) {
+	def, ok := items.Get(item.Item)
+	if !ok {
+		return
+	}
+
+	pos, height := item.InterpolatedPosition(alpha)
+	tex := assets.Texture(def.Sprite)
+	src := assets.Frame(def.Sprite, def.SpriteFrame)
+	width := src.Width * def.Scale
+	spriteHeight := src.Height * def.Scale
+
+	if height > 0 {
+		shrink := max(1-height/100, 0.5)
+		rl.DrawEllipse(int32(pos.X), int32(pos.Y+spriteHeight/2), width/2*shrink, width/6*shrink, itemShadowColor)
+	}
+
+	dest := rl.NewRectangle(pos.X-width/2, pos.Y-spriteHeight/2-height, width, spriteHeight)
+	rl.DrawTexturePro(tex, src, dest, rl.Vector2{}, 0, rl.White)
+
+	if item.Count > 1 {
+		rl.DrawText(fmt.Sprintf("%d", item.Count), int32(dest.X+dest.Width), int32(dest.Y+dest.Height)-16, 16, rl.Black)
+	}
+}
+
+// selectedSlotColor outlines the selected inventory slot.
+var selectedSlotColor = rl.NewColor(255, 200, 60, 255)
+
+// inventorySlotRect returns the cell of slot i in the bag, which is drawn as
+// a two by two grid.
+func inventorySlotRect(bag rl.Rectangle, i int) rl.Rectangle {
+	w, h := bag.Width/2, bag.Height/2
+	return rl.NewRectangle(bag.X+float32(i%2)*w, bag.Y+float32(i/2)*h, w, h)
+}
+
+// drawInventorySlot draws a slot's icon fitted to its cell, with the stack
+// size in the corner, and outlines the cell if the slot is selected.
+func drawInventorySlot(slot sim.InventorySlot, cell rl.Rectangle, selected bool) {
+	const margin = 0.15 // Of the cell, kept clear of the bag's frame
+
+	if selected {
+		inset := cell.Width * margin / 3
+		outline := rl.NewRectangle(cell.X+inset, cell.Y+inset, cell.Width-2*inset, cell.Height-2*inset)
+		rl.DrawRectangleLinesEx(outline, 4, selectedSlotColor)
+	}
+
+	def, ok := items.Get(slot.Item)
+	if slot.Empty() || !ok {
+		return
+	}
+
+	icon := assets.Texture(def.Icon)
+	src := assets.Frame(def.Icon, def.IconFrame)
+	box := cell.Width * (1 - 2*margin)
+	scale := min(box/src.Width, box/src.Height)
+	width, height := src.Width*scale, src.Height*scale
+
+	dest := rl.NewRectangle(cell.X+(cell.Width-width)/2, cell.Y+(cell.Height-height)/2, width, height)
+	rl.DrawTexturePro(icon, src, dest, rl.Vector2{}, 0, rl.White)
+
+	const textSize = 24
+	count := fmt.Sprintf("%d", slot.Count)
+	textX := int32(cell.X+cell.Width*(1-margin)) - rl.MeasureText(count, textSize)
+	textY := int32(cell.Y+cell.Height*(1-margin)) - textSize
+	rl.DrawText(count, textX, textY, textSize, rl.White)
+}
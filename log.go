@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"main/logging"
+)
+
+var (
+	logLevel = flag.String("log-level", "info", "log level, optionally per category: warn,trees=debug,input=debug")
+	logFile  = flag.String("log-file", "", "also write the log to this file")
+
+	assetsLog  = logging.For("assets")
+	displayLog = logging.For("display")
+	inputLog   = logging.For("input")
+	replayLog  = logging.For("replay")
+	saveLog    = logging.For("save")
+	worldLog   = logging.For("world")
+
+	logOutput *os.File
+)
+
+// setupLogging applies -log-level and -log-file. Bad flags are fatal, since
+// they're only ever given by hand.
+func setupLogging() {
+	levels, err := logging.ParseLevels(*logLevel)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "-log-level:", err)
+		os.Exit(2)
+	}
+
+	if *logFile != "" {
+		logOutput, err = os.OpenFile(*logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "-log-file:", err)
+			os.Exit(2)
+		}
+		logging.Setup(levels, logOutput)
+		return
+	}
+	logging.Setup(levels, nil)
+}
+
+func closeLogging() {
+	if logOutput != nil {
+		logOutput.Close()
+	}
+}
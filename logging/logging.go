@@ -0,0 +1,168 @@
+// Package logging gives each subsystem its own leveled log/slog logger.
+// Every record carries the subsystem's name as its "cat" attribute, and the
+// level can be set for all categories at once or per category, so one
+// noisy system can be turned up without flooding the terminal with the rest.
+//
+// Loggers can be created at package init time; Setup decides where their
+// output goes and can be called afterwards.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Levels controls which records are logged: Default for every category
+// without an entry in ByCategory.
+type Levels struct {
+	Default    slog.Level
+	ByCategory map[string]slog.Level
+}
+
+// ParseLevels parses a level spec: a default level, optionally followed by
+// per-category overrides, such as "info" or "warn,trees=debug,input=debug".
+func ParseLevels(spec string) (Levels, error) {
+	levels := Levels{Default: slog.LevelInfo, ByCategory: make(map[string]slog.Level)}
+	for i, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		cat, name, hasCat := strings.Cut(part, "=")
+		if !hasCat {
+			name = part
+		}
+
+		var l slog.Level
+		if err := l.UnmarshalText([]byte(name)); err != nil {
+			return levels, fmt.Errorf("log level %q: want debug, info, warn or error", name)
+		}
+
+		switch {
+		case hasCat:
+			levels.ByCategory[strings.TrimSpace(cat)] = l
+		case i == 0:
+			levels.Default = l
+		default:
+			return levels, fmt.Errorf("log level %q: only the first entry may leave out the category", part)
+		}
+	}
+	return levels, nil
+}
+
+var (
+	mu     sync.RWMutex
+	out    slog.Handler = slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})
+	levels              = Levels{Default: slog.LevelInfo}
+)
+
+// Setup sends logs at the given levels to stderr and, if w isn't nil, to w
+// as well.
+func Setup(l Levels, w io.Writer) {
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug} // Filtering happens per category
+	var h slog.Handler = slog.NewTextHandler(os.Stderr, opts)
+	if w != nil {
+		h = fanout{h, slog.NewTextHandler(w, opts)}
+	}
+
+	mu.Lock()
+	out, levels = h, l
+	mu.Unlock()
+}
+
+// For returns the logger for one category.
+func For(category string) *slog.Logger {
+	return slog.New(&handler{category: category})
+}
+
+func enabled(category string, l slog.Level) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	threshold, ok := levels.ByCategory[category]
+	if !ok {
+		threshold = levels.Default
+	}
+	return l >= threshold
+}
+
+func output() slog.Handler {
+	mu.RLock()
+	defer mu.RUnlock()
+	return out
+}
+
+// handler checks a category's level, then passes records on to whatever
+// output Setup chose last.
+type handler struct {
+	category string
+	with     []func(slog.Handler) slog.Handler // WithAttrs and WithGroup calls, in order
+}
+
+func (h *handler) Enabled(_ context.Context, l slog.Level) bool {
+	return enabled(h.category, l)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	dst := output().WithAttrs([]slog.Attr{slog.String("cat", h.category)})
+	for _, with := range h.with {
+		dst = with(dst)
+	}
+	return dst.Handle(ctx, r)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.derive(func(dst slog.Handler) slog.Handler { return dst.WithAttrs(attrs) })
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return h.derive(func(dst slog.Handler) slog.Handler { return dst.WithGroup(name) })
+}
+
+func (h *handler) derive(with func(slog.Handler) slog.Handler) *handler {
+	return &handler{
+		category: h.category,
+		with:     append(h.with[:len(h.with):len(h.with)], with),
+	}
+}
+
+// fanout sends every record to several handlers.
+type fanout []slog.Handler
+
+func (f fanout) Enabled(ctx context.Context, l slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, l) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanout) Handle(ctx context.Context, r slog.Record) error {
+	var first error
+	for _, h := range f {
+		if h.Enabled(ctx, r.Level) {
+			if err := h.Handle(ctx, r.Clone()); err != nil && first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+func (f fanout) WithAttrs(attrs []slog.Attr) slog.Handler {
+	g := make(fanout, len(f))
+	for i, h := range f {
+		g[i] = h.WithAttrs(attrs)
+	}
+	return g
+}
+
+func (f fanout) WithGroup(name string) slog.Handler {
+	g := make(fanout, len(f))
+	for i, h := range f {
+		g[i] = h.WithGroup(name)
+	}
+	return g
+}
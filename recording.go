@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"main/replay"
+	"main/sim"
+)
+
+var (
+	recordPath = flag.String("record", "", "record the seed and every tick's input to this file")
+	replayPath = flag.String("replay", "", "play back a recording made with -record")
+
+	recorder *replay.Recorder
+	playback *replay.Replay // Nil once playback finishes or diverges
+)
+
+// startReplay builds the world from the recording at -replay.
+func startReplay() error {
+	rp, err := replay.Open(*replayPath)
+	if err != nil {
+		return err
+	}
+	if rp.Header.Dt != fixedDt {
+		return fmt.Errorf("%s was recorded at %g seconds per tick, this build runs at %g", *replayPath, rp.Header.Dt, fixedDt)
+	}
+	if rp.Header.Config.MapPath != *mapPath {
+		return fmt.Errorf("%s was recorded on map %q, this game is playing %q", *replayPath, rp.Header.Config.MapPath, *mapPath)
+	}
+
+	playback = rp
+	world = rp.NewWorld(items, worldMap)
+	replayLog.Info("replaying", "path", *replayPath, "ticks", rp.Ticks())
+	showStatus("Replaying")
+	return nil
+}
+
+// startRecording begins recording to -record, if it was given.
+func startRecording() {
+	if *recordPath == "" {
+		return
+	}
+
+	var err error
+	recorder, err = replay.Create(*recordPath, world, fixedDt)
+	if err != nil {
+		replayLog.Error("can't record", "err", err)
+		return
+	}
+	replayLog.Info("recording", "path", *recordPath)
+}
+
+// stepWorld runs one fixed-size simulation step. While a replay is playing
+// the recorded inputs drive the world and live input is ignored; once it
+// ends the player takes over from where it left off.
+func stepWorld(in sim.Inputs) {
+	if playback != nil {
+		if err := playback.Step(world); err != nil {
+			replayLog.Error("replay diverged", "err", err)
+			showStatus("Replay diverged")
+			playback = nil
+			return
+		}
+		if playback.Done() {
+			replayLog.Info("replay finished", "ticks", playback.Tick(), "hash", fmt.Sprintf("%016x", world.Hash()))
+			showStatus("Replay finished")
+			playback = nil
+		}
+		return
+	}
+
+	world.Step(in, fixedDt)
+
+	if recorder != nil {
+		if err := recorder.Record(in, world); err != nil {
+			replayLog.Error("recording failed", "err", err)
+			stopRecording()
+		}
+	}
+}
+
+// recordingOrReplaying reports whether the world has to stay on the path its
+// inputs dictate, so nothing else, like loading a save, may change it.
+func recordingOrReplaying() bool {
+	return recorder != nil || playback != nil
+}
+
+func stopRecording() {
+	if recorder == nil {
+		return
+	}
+	if err := recorder.Close(); err != nil {
+		replayLog.Error("recording failed", "err", err)
+	}
+	recorder = nil
+}
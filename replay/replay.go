@@ -0,0 +1,233 @@
+// Package replay records the inputs fed to a sim.World and plays them back.
+// A recording holds the world's config and starting state plus the inputs
+// for every tick, so playing it back rebuilds the exact same world. Hashes
+// of the world are written along the way and checked on playback, which
+// catches nondeterminism at the tick where it first shows up.
+//
+// Recordings are JSON lines: a header, then one line for each tick whose
+// inputs differ from the previous tick's, and one line for each state hash.
+package replay
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"main/sim"
+	"main/tilemap"
+)
+
+// Version is the recording format version. Bump it when sim.Inputs,
+// sim.Config or sim.SaveData change shape, or what a world does with them,
+// such as reseeding from the save's seed.
+const Version = 9
+
+// DefaultHashEvery is how many ticks pass between state hashes.
+const DefaultHashEvery = 60
+
+// Header is the first line of a recording.
+type Header struct {
+	Version   int          `json:"version"`
+	Config    sim.Config   `json:"config"`
+	Start     sim.SaveData `json:"start"`
+	Dt        float32      `json:"dt"`        // Seconds per tick
+	HashEvery int          `json:"hashEvery"` // Ticks between state hashes
+}
+
+// entry is a line after the header. Tick counts from 0 at the start of the
+// recording.
+type entry struct {
+	Tick   int         `json:"tick"`
+	Inputs *sim.Inputs `json:"inputs,omitempty"` // Inputs from this tick on
+	Hash   *uint64     `json:"hash,omitempty"`   // State after this tick
+}
+
+// Recorder writes a recording as the game runs.
+type Recorder struct {
+	f      *os.File
+	w      *bufio.Writer
+	enc    *json.Encoder
+	header Header
+	tick   int
+	last   sim.Inputs
+}
+
+// Create starts a recording at path of the given world, which must not have
+// been stepped since it was built or loaded. The world is reloaded from its
+// own save data, so it matches exactly what playback will rebuild.
+func Create(path string, world *sim.World, dt float32) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Recorder{
+		f: f,
+		w: bufio.NewWriter(f),
+		header: Header{
+			Version:   Version,
+			Config:    world.Config,
+			Start:     world.Save(),
+			Dt:        dt,
+			HashEvery: DefaultHashEvery,
+		},
+	}
+	world.Load(r.header.Start)
+
+	r.enc = json.NewEncoder(r.w)
+	if err := r.enc.Encode(r.header); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// Record notes the inputs of the tick that was just stepped, and the world's
+// hash when one is due. Call it right after each world.Step.
+func (r *Recorder) Record(in sim.Inputs, world *sim.World) error {
+	if r.tick == 0 || in != r.last {
+		in := in
+		if err := r.enc.Encode(entry{Tick: r.tick, Inputs: &in}); err != nil {
+			return err
+		}
+		r.last = in
+	}
+
+	if (r.tick+1)%r.header.HashEvery == 0 {
+		hash := world.Hash()
+		if err := r.enc.Encode(entry{Tick: r.tick, Hash: &hash}); err != nil {
+			return err
+		}
+	}
+
+	r.tick++
+	return nil
+}
+
+// Close marks the end of the recording, then flushes and closes it.
+func (r *Recorder) Close() error {
+	if r.tick > 0 {
+		// An empty entry on the last tick, so the recording's length is known
+		// even if nothing changed near the end.
+		if err := r.enc.Encode(entry{Tick: r.tick - 1}); err != nil {
+			r.f.Close()
+			return err
+		}
+	}
+	if err := r.w.Flush(); err != nil {
+		r.f.Close()
+		return err
+	}
+	return r.f.Close()
+}
+
+// Replay plays a recording back.
+type Replay struct {
+	Header Header
+
+	entries []entry
+	next    int // Index of the next unread entry
+	tick    int
+	inputs  sim.Inputs
+	last    int // Last tick with recorded inputs or hashes
+}
+
+// Open reads the recording at path.
+func Open(path string) (*Replay, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Read reads a recording.
+func Read(r io.Reader) (*Replay, error) {
+	dec := json.NewDecoder(bufio.NewReader(r))
+
+	rp := &Replay{}
+	if err := dec.Decode(&rp.Header); err != nil {
+		return nil, fmt.Errorf("reading replay header: %w", err)
+	}
+	switch v := rp.Header.Version; {
+	case v < Version:
+		return nil, fmt.Errorf("replay is version %d, recorded by an older game; this game only plays version %d", v, Version)
+	case v > Version:
+		return nil, fmt.Errorf("replay is version %d, recorded by a newer game; this game only plays version %d", v, Version)
+	}
+	if rp.Header.HashEvery <= 0 || rp.Header.Dt <= 0 {
+		return nil, fmt.Errorf("replay header is invalid")
+	}
+
+	for {
+		var e entry
+		err := dec.Decode(&e)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading replay: %w", err)
+		}
+		rp.entries = append(rp.entries, e)
+		rp.last = max(rp.last, e.Tick)
+	}
+	return rp, nil
+}
+
+// NewWorld builds the world the recording started from. Item definitions
+// and the map aren't recorded, so the caller passes the ones the game ships
+// with; the map must be the one named by Header.Config.MapPath.
+func (rp *Replay) NewWorld(items *sim.ItemRegistry, tiles *tilemap.Map) *sim.World {
+	cfg := rp.Header.Config
+	cfg.Items = items
+	cfg.Map = tiles
+	w := sim.NewWorld(cfg)
+	w.Load(rp.Header.Start)
+	return w
+}
+
+// Ticks is the length of the recording in ticks.
+func (rp *Replay) Ticks() int {
+	if len(rp.entries) == 0 {
+		return 0
+	}
+	return rp.last + 1
+}
+
+// Done reports whether every recorded tick has been played.
+func (rp *Replay) Done() bool {
+	return rp.tick >= rp.Ticks()
+}
+
+// Step advances world by one recorded tick and checks it against the
+// recording. It returns an error the first time the world's hash doesn't
+// match, meaning playback has diverged.
+func (rp *Replay) Step(world *sim.World) error {
+	for rp.next < len(rp.entries) && rp.entries[rp.next].Tick == rp.tick && rp.entries[rp.next].Inputs != nil {
+		rp.inputs = *rp.entries[rp.next].Inputs
+		rp.next++
+	}
+
+	world.Step(rp.inputs, rp.Header.Dt)
+
+	var err error
+	for rp.next < len(rp.entries) && rp.entries[rp.next].Tick == rp.tick {
+		if want := rp.entries[rp.next].Hash; want != nil {
+			if got := world.Hash(); got != *want && err == nil {
+				err = fmt.Errorf("replay diverged at tick %d: state hash %016x, recorded %016x", rp.tick, got, *want)
+			}
+		}
+		rp.next++
+	}
+
+	rp.tick++
+	return err
+}
+
+// Tick is the number of ticks played so far.
+func (rp *Replay) Tick() int {
+	return rp.tick
+}
@@ -0,0 +1,153 @@
+package replay
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"main/res"
+	"main/sim"
+)
+
+const dt = 1.0 / 60
+
+func testItems(t *testing.T) *sim.ItemRegistry {
+	t.Helper()
+	items, err := sim.LoadItems(res.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return items
+}
+
+// script returns the inputs for each tick of a recording.
+type script func(tick int) sim.Inputs
+
+// record runs script for ticks on a new world and returns the recording's
+// path and the world's final hash.
+func record(t *testing.T, items *sim.ItemRegistry, ticks int, in script) (string, uint64) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "run.jsonl")
+	world := sim.NewWorld(sim.Config{Seed: 7, ViewWidth: 1920, CloudWidth: 2000, Items: items})
+	rec, err := Create(path, world, dt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for tick := range ticks {
+		inputs := in(tick)
+		world.Step(inputs, dt)
+		if err := rec.Record(inputs, world); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path, world.Hash()
+}
+
+func TestRoundTrip(t *testing.T) {
+	items := testItems(t)
+	tests := []struct {
+		name  string
+		ticks int
+		in    script
+	}{
+		{"idle", 150, func(int) sim.Inputs { return sim.Inputs{} }},
+		{"walk", 150, func(tick int) sim.Inputs {
+			return sim.Inputs{MoveX: 1, MoveY: float32(tick%40/20*2 - 1)}
+		}},
+		{"drop and pick up", 200, func(tick int) sim.Inputs {
+			return sim.Inputs{Drop: tick%30 == 0, Interact: tick%50 == 49, MoveX: -0.5}
+		}},
+		{"plant and chop", 300, func(tick int) sim.Inputs {
+			// Drop a pine cone, plant it before it floats back, then swing
+			// the axe at the seedling.
+			in := sim.Inputs{Drop: tick == 0, Plant: tick == 60, Use: tick > 80 && tick%15 == 0}
+			if tick == 70 {
+				in.Select = 3
+			}
+			return in
+		}},
+		{"ends idle", 130, func(tick int) sim.Inputs {
+			if tick < 10 {
+				return sim.Inputs{MoveY: 1}
+			}
+			return sim.Inputs{}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, want := record(t, items, tt.ticks, tt.in)
+
+			rp, err := Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rp.Ticks() != tt.ticks {
+				t.Errorf("Ticks = %d, want %d", rp.Ticks(), tt.ticks)
+			}
+			world := rp.NewWorld(items, nil)
+			for !rp.Done() {
+				if err := rp.Step(world); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if rp.Tick() != tt.ticks {
+				t.Errorf("played %d ticks, want %d", rp.Tick(), tt.ticks)
+			}
+			if got := world.Hash(); got != want {
+				t.Errorf("final state %016x, recorded world ended at %016x", got, want)
+			}
+		})
+	}
+}
+
+func TestDiverged(t *testing.T) {
+	items := testItems(t)
+	path, _ := record(t, items, 150, func(int) sim.Inputs { return sim.Inputs{MoveX: 1} })
+
+	rp, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	world := rp.NewWorld(items, nil)
+	world.Player.Dest.X, world.Player.Dest.Y = 1000, 1000
+	for !rp.Done() {
+		if err = rp.Step(world); err != nil {
+			break
+		}
+	}
+	// The first hash is checked after tick 59.
+	if err == nil || !strings.Contains(err.Error(), "diverged at tick 59") {
+		t.Errorf("Step = %v, want a divergence at tick 59", err)
+	}
+}
+
+func TestReadHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  Header
+		wantErr string
+	}{
+		{"current", Header{Version: Version, Dt: dt, HashEvery: 60}, ""},
+		{"older", Header{Version: Version - 1, Dt: dt, HashEvery: 60}, "recorded by an older game"},
+		{"first", Header{Version: 1, Dt: dt, HashEvery: 60}, "recorded by an older game"},
+		{"newer", Header{Version: Version + 1, Dt: dt, HashEvery: 60}, "recorded by a newer game"},
+		{"no hashes", Header{Version: Version, Dt: dt}, "header is invalid"},
+		{"no dt", Header{Version: Version, HashEvery: 60}, "header is invalid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line, err := json.Marshal(tt.header)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = Read(strings.NewReader(string(line) + "\n"))
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Read = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,7 @@
+<?xml version="1.0" encoding="UTF-8"?>
+<tileset version="1.10" tiledversion="1.10.2" name="Fences" tilewidth="16" tileheight="16" tilecount="16" columns="4">
+ <properties>
+  <property name="solid" type="bool" value="true"/>
+ </properties>
+ <image source="Fences.png" width="64" height="64"/>
+</tileset>
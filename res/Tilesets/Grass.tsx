@@ -0,0 +1,138 @@
+<?xml version="1.0" encoding="UTF-8"?>
+<tileset version="1.10" tiledversion="1.10.2" name="Grass" tilewidth="16" tileheight="16" tilecount="77" columns="11">
+ <image source="Grass.png" width="176" height="112"/>
+ <tile id="12">
+  <properties>
+   <property name="tillable" type="bool" value="true"/>
+  </properties>
+ </tile>
+ <tile id="55" probability="0.02">
+  <properties>
+   <property name="tillable" type="bool" value="true"/>
+  </properties>
+ </tile>
+ <tile id="56" probability="0.02">
+  <properties>
+   <property name="tillable" type="bool" value="true"/>
+  </properties>
+ </tile>
+ <tile id="57" probability="0.02">
+  <properties>
+   <property name="tillable" type="bool" value="true"/>
+  </properties>
+ </tile>
+ <tile id="58" probability="0.02">
+  <properties>
+   <property name="tillable" type="bool" value="true"/>
+  </properties>
+ </tile>
+ <tile id="59" probability="0.02">
+  <properties>
+   <property name="tillable" type="bool" value="true"/>
+  </properties>
+ </tile>
+ <tile id="60" probability="0.02">
+  <properties>
+   <property name="tillable" type="bool" value="true"/>
+  </properties>
+ </tile>
+ <tile id="66" probability="0.02">
+  <properties>
+   <property name="tillable" type="bool" value="true"/>
+  </properties>
+ </tile>
+ <tile id="67" probability="0.02">
+  <properties>
+   <property name="tillable" type="bool" value="true"/>
+  </properties>
+ </tile>
+ <tile id="68" probability="0.02">
+  <properties>
+   <property name="tillable" type="bool" value="true"/>
+  </properties>
+ </tile>
+ <tile id="69" probability="0.02">
+  <properties>
+   <property name="tillable" type="bool" value="true"/>
+  </properties>
+ </tile>
+ <tile id="70" probability="0.02">
+  <properties>
+   <property name="tillable" type="bool" value="true"/>
+  </properties>
+ </tile>
+ <tile id="71" probability="0.02">
+  <properties>
+   <property name="tillable" type="bool" value="true"/>
+  </properties>
+ </tile>
+ <wangsets>
+  <wangset name="Grass" type="mixed" tile="12">
+   <wangcolor name="Grass" color="#7cb342" tile="12" probability="1"/>
+   <wangtile tileid="0" wangid="0,0,1,1,1,0,0,0"/>
+   <wangtile tileid="1" wangid="0,0,1,1,1,1,1,0"/>
+   <wangtile tileid="2" wangid="0,0,0,0,1,1,1,0"/>
+   <wangtile tileid="3" wangid="0,0,0,0,1,0,0,0"/>
+   <wangtile tileid="4" wangid="0,0,1,0,1,0,0,0"/>
+   <wangtile tileid="5" wangid="0,0,1,0,1,1,1,0"/>
+   <wangtile tileid="6" wangid="0,0,1,1,1,0,1,0"/>
+   <wangtile tileid="7" wangid="0,0,0,0,1,0,1,0"/>
+   <wangtile tileid="8" wangid="0,0,1,0,1,0,1,0"/>
+   <wangtile tileid="9" wangid="1,1,1,0,1,1,1,0"/>
+   <wangtile tileid="11" wangid="1,1,1,1,1,0,0,0"/>
+   <wangtile tileid="12" wangid="1,1,1,1,1,1,1,1"/>
+   <wangtile tileid="13" wangid="1,0,0,0,1,1,1,1"/>
+   <wangtile tileid="14" wangid="1,0,0,0,1,0,0,0"/>
+   <wangtile tileid="15" wangid="1,1,1,0,1,0,0,0"/>
+   <wangtile tileid="16" wangid="1,1,1,0,1,1,1,1"/>
+   <wangtile tileid="17" wangid="1,1,1,1,1,0,1,1"/>
+   <wangtile tileid="18" wangid="1,0,0,0,1,0,1,1"/>
+   <wangtile tileid="19" wangid="1,1,1,0,1,0,1,1"/>
+   <wangtile tileid="20" wangid="1,0,1,1,1,0,1,1"/>
+   <wangtile tileid="22" wangid="1,1,1,0,0,0,0,0"/>
+   <wangtile tileid="23" wangid="1,1,1,0,0,0,1,1"/>
+   <wangtile tileid="24" wangid="1,0,0,0,0,0,1,1"/>
+   <wangtile tileid="25" wangid="1,0,0,0,0,0,0,0"/>
+   <wangtile tileid="26" wangid="1,0,1,1,1,0,0,0"/>
+   <wangtile tileid="27" wangid="1,0,1,1,1,1,1,1"/>
+   <wangtile tileid="28" wangid="1,1,1,1,1,1,1,0"/>
+   <wangtile tileid="29" wangid="1,0,0,0,1,1,1,0"/>
+   <wangtile tileid="30" wangid="1,0,1,1,1,1,1,0"/>
+   <wangtile tileid="31" wangid="1,0,1,1,1,0,1,0"/>
+   <wangtile tileid="32" wangid="1,0,1,0,1,1,1,0"/>
+   <wangtile tileid="33" wangid="0,0,1,0,0,0,0,0"/>
+   <wangtile tileid="34" wangid="0,0,1,0,0,0,1,0"/>
+   <wangtile tileid="35" wangid="0,0,0,0,0,0,1,0"/>
+   <wangtile tileid="36" wangid="0,0,0,0,0,0,0,0"/>
+   <wangtile tileid="37" wangid="1,0,1,0,0,0,0,0"/>
+   <wangtile tileid="38" wangid="1,0,1,0,0,0,1,1"/>
+   <wangtile tileid="39" wangid="1,1,1,0,0,0,1,0"/>
+   <wangtile tileid="40" wangid="1,0,0,0,0,0,1,0"/>
+   <wangtile tileid="41" wangid="1,0,1,0,0,0,1,0"/>
+   <wangtile tileid="42" wangid="1,1,1,0,1,0,1,0"/>
+   <wangtile tileid="43" wangid="1,0,1,0,1,0,1,1"/>
+   <wangtile tileid="44" wangid="1,0,0,0,1,0,0,0"/>
+   <wangtile tileid="45" wangid="0,0,1,0,0,0,1,0"/>
+   <wangtile tileid="46" wangid="0,0,0,0,0,0,0,0"/>
+   <wangtile tileid="47" wangid="0,0,0,0,0,0,0,0"/>
+   <wangtile tileid="48" wangid="1,0,1,0,1,0,0,0"/>
+   <wangtile tileid="49" wangid="1,0,1,0,1,1,1,1"/>
+   <wangtile tileid="50" wangid="1,1,1,1,1,0,1,0"/>
+   <wangtile tileid="51" wangid="1,0,0,0,1,0,1,0"/>
+   <wangtile tileid="52" wangid="1,0,1,0,1,0,1,0"/>
+   <wangtile tileid="53" wangid="1,0,1,0,1,0,1,0"/>
+   <wangtile tileid="55" wangid="1,1,1,1,1,1,1,1"/>
+   <wangtile tileid="56" wangid="1,1,1,1,1,1,1,1"/>
+   <wangtile tileid="57" wangid="1,1,1,1,1,1,1,1"/>
+   <wangtile tileid="58" wangid="1,1,1,1,1,1,1,1"/>
+   <wangtile tileid="59" wangid="1,1,1,1,1,1,1,1"/>
+   <wangtile tileid="60" wangid="1,1,1,1,1,1,1,1"/>
+   <wangtile tileid="66" wangid="1,1,1,1,1,1,1,1"/>
+   <wangtile tileid="67" wangid="1,1,1,1,1,1,1,1"/>
+   <wangtile tileid="68" wangid="1,1,1,1,1,1,1,1"/>
+   <wangtile tileid="69" wangid="1,1,1,1,1,1,1,1"/>
+   <wangtile tileid="70" wangid="1,1,1,1,1,1,1,1"/>
+   <wangtile tileid="71" wangid="1,1,1,1,1,1,1,1"/>
+  </wangset>
+ </wangsets>
+</tileset>
@@ -0,0 +1,93 @@
+<?xml version="1.0" encoding="UTF-8"?>
+<tileset version="1.10" tiledversion="1.10.2" name="Grass Biome" tilewidth="16" tileheight="16" tilecount="45" columns="9">
+ <image source="../Objects/Basic_Grass_Biom_things.png" width="144" height="80"/>
+ <tile id="5">
+  <properties>
+   <property name="biome" value="forest"/>
+  </properties>
+ </tile>
+ <tile id="6">
+  <properties>
+   <property name="biome" value="forest"/>
+  </properties>
+ </tile>
+ <tile id="7">
+  <properties>
+   <property name="biome" value="forest"/>
+  </properties>
+ </tile>
+ <tile id="8">
+  <properties>
+   <property name="biome" value="forest"/>
+  </properties>
+ </tile>
+ <tile id="14">
+  <properties>
+   <property name="biome" value="forest"/>
+  </properties>
+ </tile>
+ <tile id="15">
+  <properties>
+   <property name="biome" value="meadow"/>
+  </properties>
+ </tile>
+ <tile id="16" probability="0.5">
+  <properties>
+   <property name="biome" value="meadow"/>
+  </properties>
+ </tile>
+ <tile id="17" probability="0.2">
+  <properties>
+   <property name="biome" value="meadow"/>
+   <property name="solid" type="bool" value="true"/>
+  </properties>
+ </tile>
+ <tile id="23" probability="0.3">
+  <properties>
+   <property name="biome" value="forest"/>
+   <property name="solid" type="bool" value="true"/>
+  </properties>
+ </tile>
+ <tile id="24">
+  <properties>
+   <property name="biome" value="meadow"/>
+  </properties>
+ </tile>
+ <tile id="25">
+  <properties>
+   <property name="biome" value="meadow"/>
+  </properties>
+ </tile>
+ <tile id="32">
+  <properties>
+   <property name="biome" value="meadow"/>
+  </properties>
+ </tile>
+ <tile id="33">
+  <properties>
+   <property name="biome" value="meadow"/>
+  </properties>
+ </tile>
+ <tile id="34">
+  <properties>
+   <property name="biome" value="meadow"/>
+  </properties>
+ </tile>
+ <tile id="42" probability="0.5">
+  <properties>
+   <property name="biome" value="meadow"/>
+  </properties>
+ </tile>
+ <tile id="43">
+  <properties>
+   <property name="biome" value="pond"/>
+   <property name="solid" type="bool" value="true"/>
+  </properties>
+ </tile>
+ <tile id="44">
+  <properties>
+   <property name="biome" value="pond"/>
+   <property name="solid" type="bool" value="true"/>
+  </properties>
+ </tile>
+</tileset>
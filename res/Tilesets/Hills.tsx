@@ -0,0 +1,64 @@
+<?xml version="1.0" encoding="UTF-8"?>
+<tileset version="1.10" tiledversion="1.10.2" name="Hills" tilewidth="16" tileheight="16" tilecount="77" columns="11">
+ <image source="Hills.png" width="176" height="112"/>
+ <tile id="22">
+  <properties>
+   <property name="solid" type="bool" value="true"/>
+  </properties>
+ </tile>
+ <tile id="23">
+  <properties>
+   <property name="solid" type="bool" value="true"/>
+  </properties>
+ </tile>
+ <tile id="24">
+  <properties>
+   <property name="solid" type="bool" value="true"/>
+  </properties>
+ </tile>
+ <tile id="25">
+  <properties>
+   <property name="solid" type="bool" value="true"/>
+  </properties>
+ </tile>
+ <tile id="33">
+  <properties>
+   <property name="solid" type="bool" value="true"/>
+  </properties>
+ </tile>
+ <tile id="34">
+  <properties>
+   <property name="solid" type="bool" value="true"/>
+  </properties>
+ </tile>
+ <tile id="35">
+  <properties>
+   <property name="solid" type="bool" value="true"/>
+  </properties>
+ </tile>
+ <tile id="36">
+  <properties>
+   <property name="solid" type="bool" value="true"/>
+  </properties>
+ </tile>
+ <wangsets>
+  <wangset name="Hills" type="edge" tile="12">
+   <wangcolor name="Hills" color="#8d6e4a" tile="12" probability="1"/>
+   <wangtile tileid="0" wangid="0,0,1,0,1,0,0,0"/>
+   <wangtile tileid="1" wangid="0,0,1,0,1,0,1,0"/>
+   <wangtile tileid="2" wangid="0,0,0,0,1,0,1,0"/>
+   <wangtile tileid="3" wangid="0,0,0,0,1,0,0,0"/>
+   <wangtile tileid="11" wangid="1,0,1,0,1,0,0,0"/>
+   <wangtile tileid="12" wangid="1,0,1,0,1,0,1,0"/>
+   <wangtile tileid="13" wangid="1,0,0,0,1,0,1,0"/>
+   <wangtile tileid="14" wangid="1,0,0,0,1,0,0,0"/>
+   <wangtile tileid="22" wangid="1,0,1,0,0,0,0,0"/>
+   <wangtile tileid="23" wangid="1,0,1,0,0,0,1,0"/>
+   <wangtile tileid="24" wangid="1,0,0,0,0,0,1,0"/>
+   <wangtile tileid="25" wangid="1,0,0,0,0,0,0,0"/>
+   <wangtile tileid="33" wangid="0,0,1,0,0,0,0,0"/>
+   <wangtile tileid="34" wangid="0,0,1,0,0,0,1,0"/>
+   <wangtile tileid="35" wangid="0,0,0,0,0,0,1,0"/>
+  </wangset>
+ </wangsets>
+</tileset>
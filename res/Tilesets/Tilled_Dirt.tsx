@@ -0,0 +1,46 @@
+<?xml version="1.0" encoding="UTF-8"?>
+<tileset version="1.10" tiledversion="1.10.2" name="Tilled_Dirt" tilewidth="16" tileheight="16" tilecount="77" columns="11">
+ <image source="Tilled_Dirt.png" width="176" height="112"/>
+ <wangsets>
+  <wangset name="Tilled Dirt" type="mixed" tile="12">
+   <wangcolor name="Tilled Dirt" color="#c8a26b" tile="12" probability="1"/>
+   <wangtile tileid="4" wangid="0,0,1,0,1,0,0,0"/>
+   <wangtile tileid="5" wangid="0,0,1,0,1,1,1,0"/>
+   <wangtile tileid="6" wangid="0,0,1,1,1,0,1,0"/>
+   <wangtile tileid="7" wangid="0,0,0,0,1,0,1,0"/>
+   <wangtile tileid="8" wangid="0,0,1,0,1,0,1,0"/>
+   <wangtile tileid="9" wangid="1,0,1,1,1,0,1,1"/>
+   <wangtile tileid="12" wangid="1,1,1,1,1,1,1,1"/>
+   <wangtile tileid="14" wangid="1,0,0,0,1,0,0,0"/>
+   <wangtile tileid="15" wangid="1,1,1,0,1,0,0,0"/>
+   <wangtile tileid="18" wangid="1,0,0,0,1,0,1,1"/>
+   <wangtile tileid="19" wangid="1,1,1,0,1,0,1,1"/>
+   <wangtile tileid="20" wangid="1,1,1,0,1,1,1,0"/>
+   <wangtile tileid="26" wangid="1,0,1,1,1,0,0,0"/>
+   <wangtile tileid="29" wangid="1,0,0,0,1,1,1,0"/>
+   <wangtile tileid="30" wangid="1,0,1,1,1,1,1,0"/>
+   <wangtile tileid="31" wangid="1,0,1,1,1,0,1,0"/>
+   <wangtile tileid="32" wangid="1,0,1,0,1,1,1,0"/>
+   <wangtile tileid="34" wangid="0,0,1,0,0,0,1,0"/>
+   <wangtile tileid="36" wangid="0,0,0,0,0,0,0,0"/>
+   <wangtile tileid="37" wangid="1,0,1,0,0,0,0,0"/>
+   <wangtile tileid="38" wangid="1,0,1,0,0,0,1,1"/>
+   <wangtile tileid="39" wangid="1,1,1,0,0,0,1,0"/>
+   <wangtile tileid="40" wangid="1,0,0,0,0,0,1,0"/>
+   <wangtile tileid="41" wangid="1,0,1,0,0,0,1,0"/>
+   <wangtile tileid="42" wangid="1,1,1,0,1,0,1,0"/>
+   <wangtile tileid="43" wangid="1,0,1,0,1,0,1,1"/>
+   <wangtile tileid="48" wangid="1,0,1,0,1,0,0,0"/>
+   <wangtile tileid="49" wangid="1,0,1,0,1,1,1,1"/>
+   <wangtile tileid="50" wangid="1,1,1,1,1,0,1,0"/>
+   <wangtile tileid="51" wangid="1,0,0,0,1,0,1,0"/>
+   <wangtile tileid="52" wangid="1,0,1,0,1,0,1,0"/>
+   <wangtile tileid="55" wangid="1,1,1,1,1,1,1,1"/>
+   <wangtile tileid="56" wangid="1,1,1,1,1,1,1,1"/>
+   <wangtile tileid="57" wangid="1,1,1,1,1,1,1,1"/>
+   <wangtile tileid="66" wangid="1,1,1,1,1,1,1,1"/>
+   <wangtile tileid="67" wangid="1,1,1,1,1,1,1,1"/>
+   <wangtile tileid="68" wangid="1,1,1,1,1,1,1,1"/>
+  </wangset>
+ </wangsets>
+</tileset>
@@ -0,0 +1,16 @@
+<?xml version="1.0" encoding="UTF-8"?>
+<tileset version="1.10" tiledversion="1.10.2" name="Water" tilewidth="16" tileheight="16" tilecount="4" columns="4">
+ <properties>
+  <property name="water" type="bool" value="true"/>
+  <property name="solid" type="bool" value="true"/>
+ </properties>
+ <image source="Water.png" width="64" height="16"/>
+ <tile id="0">
+  <animation>
+   <frame tileid="0" duration="300"/>
+   <frame tileid="1" duration="300"/>
+   <frame tileid="2" duration="300"/>
+   <frame tileid="3" duration="300"/>
+  </animation>
+ </tile>
+</tileset>
@@ -0,0 +1,10 @@
+// Package res embeds the game's art and data files, so the game and headless
+// tools can read them without running from the repository root.
+package res
+
+import "embed"
+
+// FS holds every file in this directory and below.
+//
+//go:embed *
+var FS embed.FS
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+
+	"main/res"
+)
+
+// The whole res tree is built into the binary (see package res) so the game
+// runs from any directory. Everything reads it through resources.
+
+// resources is the res tree the game loads from, rooted at res/.
+var resources fs.FS
+
+// newResources returns the embedded res tree, with files under overrideDir
+// (if set) taking precedence over the embedded copies. Artists can point it
+// at a working copy of res/ to try out new art without rebuilding.
+func newResources(overrideDir string) (fs.FS, error) {
+	var embedded fs.FS = res.FS
+	if overrideDir == "" {
+		return embedded, nil
+	}
+
+	info, err := os.Stat(overrideDir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, &fs.PathError{Op: "open", Path: overrideDir, Err: errors.New("not a directory")}
+	}
+	return overlayFS{top: os.DirFS(overrideDir), base: embedded}, nil
+}
+
+// overlayFS serves files from top when it has them and from base otherwise.
+type overlayFS struct {
+	top, base fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	f, err := o.top.Open(name)
+	if err == nil {
+		return f, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return o.base.Open(name)
+}
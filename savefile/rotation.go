@@ -0,0 +1,96 @@
+package savefile
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Rotation keeps a file plus a fixed number of older backups of it. For
+// Path "autosave.json" and Backups 3 the files are autosave.json (newest),
+// then autosave.1.json through autosave.3.json.
+type Rotation struct {
+	Path    string
+	Backups int
+}
+
+// Files lists the rotation's files from newest to oldest.
+func (r Rotation) Files() []string {
+	files := []string{r.Path}
+	ext := filepath.Ext(r.Path)
+	base := strings.TrimSuffix(r.Path, ext)
+	for i := 1; i <= r.Backups; i++ {
+		files = append(files, fmt.Sprintf("%s.%d%s", base, i, ext))
+	}
+	return files
+}
+
+// Write stores data as the newest file and shifts the older ones down,
+// dropping the oldest. The new data is fully on disk, as Path plus ".new",
+// before anything is renamed, so a crash part way through still leaves a
+// complete copy for ReadNewest to find.
+func (r Rotation) Write(data []byte) error {
+	files := r.Files()
+
+	if err := WriteAtomic(r.pending(), seal(data)); err != nil {
+		return err
+	}
+
+	for i := len(files) - 1; i > 0; i-- {
+		err := os.Rename(files[i-1], files[i])
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	if err := os.Rename(r.pending(), r.Path); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(r.Path))
+	return nil
+}
+
+// pending is where Write stages new data before rotating it in.
+func (r Rotation) pending() string {
+	return r.Path + ".new"
+}
+
+// ErrNoValidFile is returned by ReadNewest when files exist but every one of
+// them is corrupt or fails validation.
+var ErrNoValidFile = errors.New("no valid save file")
+
+// ReadNewest returns the newest file in the rotation that passes its
+// checksum and the caller's valid check, along with the path it came from.
+// Files it had to skip are described in skipped. If none of the files exist
+// the error is fs.ErrNotExist; if none are usable it is ErrNoValidFile.
+func (r Rotation) ReadNewest(valid func(data []byte) error) (data []byte, path string, skipped []error, err error) {
+	// A leftover pending file means Write was interrupted after the new data
+	// was safely written, so it is the newest copy there is.
+	files := append([]string{r.pending()}, r.Files()...)
+
+	found := false
+	for _, file := range files {
+		data, err := Read(file)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		found = true
+		if err == nil && valid != nil {
+			if err = valid(data); err != nil {
+				err = fmt.Errorf("%s: %w", file, err)
+			}
+		}
+		if err != nil {
+			skipped = append(skipped, err)
+			continue
+		}
+		return data, file, skipped, nil
+	}
+
+	if !found {
+		return nil, "", nil, fs.ErrNotExist
+	}
+	return nil, "", skipped, ErrNoValidFile
+}
@@ -0,0 +1,113 @@
+// Package savefile writes save files so that a crash or power cut at any
+// moment leaves either the old file or the new one on disk, never a torn
+// mix, and detects files that were damaged anyway.
+package savefile
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// header starts every checksummed file. The rest of the line is the SHA-256
+// of everything after it.
+const header = "# sha256 "
+
+// ErrCorrupt is returned when a file's contents don't match its checksum.
+var ErrCorrupt = errors.New("save file is corrupt")
+
+// WriteAtomic replaces path with data. It writes to a temporary file in the
+// same directory, syncs it, and renames it over path, so readers only ever
+// see the complete old or complete new contents.
+func WriteAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op once the rename has happened
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	syncDir(dir)
+	return nil
+}
+
+// syncDir flushes a directory entry so a rename survives a crash. Not every
+// platform can sync a directory, so failures are ignored.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
+}
+
+// Write atomically replaces path with data behind a checksum header.
+func Write(path string, data []byte) error {
+	return WriteAtomic(path, seal(data))
+}
+
+// Read returns the data stored at path by Write, or ErrCorrupt if it fails
+// its checksum. Files without a checksum header, such as saves from before
+// checksums were added, are returned as they are.
+func Read(path string) ([]byte, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := open(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return data, nil
+}
+
+func seal(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	sealed := make([]byte, 0, len(header)+sha256.Size*2+1+len(data))
+	sealed = append(sealed, header...)
+	sealed = append(sealed, hex.EncodeToString(sum[:])...)
+	sealed = append(sealed, '\n')
+	return append(sealed, data...)
+}
+
+func open(raw []byte) ([]byte, error) {
+	if !bytes.HasPrefix(raw, []byte(header)) {
+		return raw, nil
+	}
+
+	line, data, ok := bytes.Cut(raw[len(header):], []byte("\n"))
+	if !ok {
+		return nil, ErrCorrupt
+	}
+	want, err := hex.DecodeString(string(line))
+	if err != nil || len(want) != sha256.Size {
+		return nil, ErrCorrupt
+	}
+	if sum := sha256.Sum256(data); !bytes.Equal(sum[:], want) {
+		return nil, ErrCorrupt
+	}
+	return data, nil
+}
@@ -0,0 +1,188 @@
+package savefile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	sealed := string(seal([]byte(`{"version": 9}`)))
+	tests := []struct {
+		name    string
+		file    string
+		want    string
+		wantErr error
+	}{
+		{"sealed", sealed, `{"version": 9}`, nil},
+		{"sealed empty", string(seal(nil)), "", nil},
+		{"no header", `{"version": 8}`, `{"version": 8}`, nil},
+		{"changed data", sealed[:len(sealed)-2] + "8}", "", ErrCorrupt},
+		{"truncated", sealed[:len(sealed)-1], "", ErrCorrupt},
+		{"changed checksum", header + "00" + sealed[len(header)+2:], "", ErrCorrupt},
+		{"short checksum", header + "abcd\n{}", "", ErrCorrupt},
+		{"bad hex", header + "zz\n{}", "", ErrCorrupt},
+		{"header only", header + "abcd", "", ErrCorrupt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "save.json")
+			if err := os.WriteFile(path, []byte(tt.file), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := Read(path)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Read error = %v, want %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Read = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "saves", "slot1.json")
+	for _, data := range []string{"first", "second, longer than the first", ""} {
+		if err := Write(path, []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		got, err := Read(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != data {
+			t.Errorf("Read = %q, want %q", got, data)
+		}
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("left %d files behind, want only the save", len(entries))
+	}
+}
+
+func TestRotationReadNewest(t *testing.T) {
+	// Each test writes "1" through "4" in turn, so with two backups the
+	// rotation ends up holding "4", "3" and "2", then damages some files.
+	errInvalid := errors.New("invalid")
+	tests := []struct {
+		name        string
+		damage      func(t *testing.T, r Rotation)
+		valid       func(data []byte) error
+		want        string
+		wantFile    int // Index into the rotation's files, or -1 for the pending file
+		wantSkipped int
+		wantErr     error
+	}{
+		{
+			name: "newest",
+			want: "4",
+		},
+		{
+			name:        "newest corrupt",
+			damage:      func(t *testing.T, r Rotation) { corrupt(t, r.Files()[0]) },
+			want:        "3",
+			wantFile:    1,
+			wantSkipped: 1,
+		},
+		{
+			name:        "newest missing and next corrupt",
+			damage:      func(t *testing.T, r Rotation) { os.Remove(r.Files()[0]); corrupt(t, r.Files()[1]) },
+			want:        "2",
+			wantFile:    2,
+			wantSkipped: 1,
+		},
+		{
+			name: "newest fails validation",
+			valid: func(data []byte) error {
+				if string(data) == "4" {
+					return errInvalid
+				}
+				return nil
+			},
+			want:        "3",
+			wantFile:    1,
+			wantSkipped: 1,
+		},
+		{
+			name: "interrupted write",
+			damage: func(t *testing.T, r Rotation) {
+				if err := WriteAtomic(r.pending(), seal([]byte("5"))); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want:     "5",
+			wantFile: -1,
+		},
+		{
+			name: "everything corrupt",
+			damage: func(t *testing.T, r Rotation) {
+				for _, file := range r.Files() {
+					corrupt(t, file)
+				}
+			},
+			wantSkipped: 3,
+			wantErr:     ErrNoValidFile,
+		},
+		{
+			name: "nothing there",
+			damage: func(t *testing.T, r Rotation) {
+				for _, file := range r.Files() {
+					os.Remove(file)
+				}
+			},
+			wantErr: os.ErrNotExist,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Rotation{Path: filepath.Join(t.TempDir(), "autosave.json"), Backups: 2}
+			for _, data := range []string{"1", "2", "3", "4"} {
+				if err := r.Write([]byte(data)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.damage != nil {
+				tt.damage(t, r)
+			}
+
+			got, path, skipped, err := r.ReadNewest(tt.valid)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReadNewest error = %v, want %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ReadNewest = %q, want %q", got, tt.want)
+			}
+			if len(skipped) != tt.wantSkipped {
+				t.Errorf("skipped %v, want %d files", skipped, tt.wantSkipped)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			wantPath := r.pending()
+			if tt.wantFile >= 0 {
+				wantPath = r.Files()[tt.wantFile]
+			}
+			if path != wantPath {
+				t.Errorf("read %s, want %s", path, wantPath)
+			}
+		})
+	}
+}
+
+// corrupt flips the last byte of file.
+func corrupt(t *testing.T, file string) {
+	t.Helper()
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-1] ^= 1
+	if err := os.WriteFile(file, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
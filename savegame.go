@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"main/savefile"
+	"main/sim"
+)
+
+const (
+	quickSaveSlot = "quicksave"
+	namedSlots    = 4 // Slots slot1..slot4, on the SaveSlotN and LoadSlotN actions
+)
+
+var slotNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// configDir is where settings and saves live. It's outside the working
+// directory so they survive however the game is launched.
+func configDir() string {
+	if dir, err := os.UserConfigDir(); err == nil {
+		return filepath.Join(dir, "Konno")
+	}
+	return "."
+}
+
+func saveDir() string {
+	return filepath.Join(configDir(), "saves")
+}
+
+func slotPath(slot string) (string, error) {
+	if !slotNamePattern.MatchString(slot) {
+		return "", fmt.Errorf("invalid save slot name %q", slot)
+	}
+	return filepath.Join(saveDir(), slot+".json"), nil
+}
+
+// saveGame writes the world to the named slot.
+func saveGame(slot string) error {
+	path, err := slotPath(slot)
+	if err != nil {
+		return err
+	}
+
+	data, err := sim.EncodeSave(world)
+	if err != nil {
+		return fmt.Errorf("encoding save: %w", err)
+	}
+	return savefile.Write(path, data)
+}
+
+// loadGame replaces the world with the contents of the named slot.
+func loadGame(slot string) error {
+	if recordingOrReplaying() {
+		return fmt.Errorf("can't load while recording or replaying")
+	}
+
+	path, err := slotPath(slot)
+	if err != nil {
+		return err
+	}
+
+	data, err := savefile.Read(path)
+	if err != nil {
+		return err
+	}
+	d, err := sim.DecodeSave(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	world.Load(d)
+	pendingInputs = sim.Inputs{}
+	accumulator = 0
+	return nil
+}
+
+// saveInput handles the quick-save, quick-load and save slot keys. Call it
+// after the bindings have been polled for the frame.
+func saveInput() {
+	if bindings.Pressed(ActionQuickSave) {
+		reportSave(quickSaveSlot, saveGame(quickSaveSlot))
+	}
+	if bindings.Pressed(ActionQuickLoad) {
+		reportLoad(quickSaveSlot, loadGame(quickSaveSlot))
+	}
+
+	for i := range namedSlots {
+		slot := fmt.Sprintf("slot%d", i+1)
+		if bindings.Pressed(saveSlotActions[i]) {
+			reportSave(slot, saveGame(slot))
+		}
+		if bindings.Pressed(loadSlotActions[i]) {
+			reportLoad(slot, loadGame(slot))
+		}
+	}
+}
+
+var (
+	saveSlotActions = [namedSlots]Action{ActionSaveSlot1, ActionSaveSlot2, ActionSaveSlot3, ActionSaveSlot4}
+	loadSlotActions = [namedSlots]Action{ActionLoadSlot1, ActionLoadSlot2, ActionLoadSlot3, ActionLoadSlot4}
+)
+
+func reportSave(slot string, err error) {
+	if err != nil {
+		saveLog.Error("save failed", "slot", slot, "err", err)
+		showStatus("Save failed")
+		return
+	}
+	saveLog.Info("saved game", "slot", slot)
+	showStatus("Saved to " + slot)
+}
+
+func reportLoad(slot string, err error) {
+	if err != nil {
+		saveLog.Error("load failed", "slot", slot, "err", err)
+		showStatus("Load failed")
+		return
+	}
+	saveLog.Info("loaded game", "slot", slot)
+	showStatus("Loaded " + slot)
+}
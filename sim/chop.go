@@ -0,0 +1,142 @@
+package sim
+
+import "math"
+
+const (
+	// ChopReach is how close the player has to stand to a tree's base to
+	// hit it.
+	ChopReach float32 = 120
+
+	// SwingTime is how long one swing of a tool takes, in seconds. The
+	// player can't swing again until it's over.
+	SwingTime float32 = 0.35
+
+	// ShakeTime is how long a tree shakes after a hit, in seconds.
+	ShakeTime float32 = 0.3
+
+	// StumpHits is how many hits dig up a stump.
+	StumpHits = 2
+
+	// StumpRegrowTime is how long a stump left alone takes to sprout into
+	// a sapling, in seconds of game time.
+	StumpRegrowTime float32 = 120
+
+	// WoodItem is what felled trees drop.
+	WoodItem ItemID = "wood"
+)
+
+// chopHits is how many hits fell a tree at each stage.
+var chopHits = [TreeStages]int{
+	StageSeedling: 1,
+	StageSapling:  2,
+	StageYoung:    3,
+	StageMature:   5,
+}
+
+// woodYield is how much wood a felled tree drops at each stage. Seedlings
+// are too small to leave a stump.
+var woodYield = [TreeStages]int{
+	StageSeedling: 0,
+	StageSapling:  1,
+	StageYoung:    2,
+	StageMature:   4,
+}
+
+// woodChipColor is the color of the particles a hit knocks off.
+var woodChipColor = Color{150, 100, 50, 255}
+
+// useSelected uses the item in the selected slot.
+func (w *World) useSelected() {
+	slot := w.Inventory.SelectedSlot()
+	def, ok := w.Config.Items.Get(slot.Item)
+	if slot.Empty() || !ok {
+		inputLog.Debug("nothing to use", "slot", w.Inventory.Selected+1)
+		return
+	}
+	if w.Player.SwingTime > 0 {
+		return
+	}
+
+	switch {
+	case def.HasTag(TagAxe):
+		w.Player.SwingTime = SwingTime
+		w.chop()
+	default:
+		inputLog.Debug("item has no use", "item", def.ID)
+	}
+}
+
+// chop hits the tree or stump nearest the player.
+func (w *World) chop() {
+	playerCenter := w.Player.Center()
+	key, ok := w.treeIndex().Nearest(playerCenter, ChopReach, nil)
+	if !ok {
+		treesLog.Debug("swung at nothing", "player", playerCenter)
+		return
+	}
+
+	nearest := w.treeAt(key)
+	tree := &w.Trees[nearest]
+	tree.Hits++
+	tree.Shake = ShakeTime
+	w.burst(Vec2{tree.Position.X, tree.Position.Y - 40}, 8, woodChipColor, 60, 180)
+
+	switch {
+	case tree.Stump && tree.Hits >= StumpHits:
+		treesLog.Info("dug up stump", "pos", tree.Position)
+		w.dropWood(tree.Position, 1)
+		w.removeTree(nearest)
+	case !tree.Stump && tree.Hits >= chopHits[tree.Stage]:
+		w.fell(nearest)
+	default:
+		treesLog.Debug("hit tree", "pos", tree.Position, "stage", tree.Stage, "stump", tree.Stump, "hits", tree.Hits)
+	}
+}
+
+// fell cuts down tree i, dropping its wood and leaving a stump. Seedlings
+// are simply cleared away.
+func (w *World) fell(i int) {
+	tree := &w.Trees[i]
+	treesLog.Info("felled tree", "pos", tree.Position, "stage", tree.Stage)
+	w.dropWood(tree.Position, woodYield[tree.Stage])
+
+	if tree.Stage == StageSeedling {
+		w.removeTree(i)
+		return
+	}
+	tree.Stump = true
+	tree.Hits = 0
+	tree.Age = 0
+	tree.ConeTime = 0
+}
+
+// dropWood tosses n pieces of wood out around pos.
+func (w *World) dropWood(pos Vec2, n int) {
+	if _, ok := w.Config.Items.Get(WoodItem); !ok {
+		return
+	}
+	rng := w.RNG(StreamGameplay)
+	for range n {
+		angle := rng.Float64() * 2 * math.Pi
+		distance := float64(ConeDropMin) + rng.Float64()*float64(ConeDropMax-ConeDropMin)
+		to := Vec2{
+			X: pos.X + float32(math.Cos(angle)*distance),
+			Y: pos.Y + float32(math.Sin(angle)*distance),
+		}
+		w.tossItem(WorldItem{Item: WoodItem, Count: 1}, pos, to)
+	}
+}
+
+// updateStump counts a stump toward sprouting again.
+func (w *World) updateStump(i int, grow float32) {
+	tree := &w.Trees[i]
+	tree.Age += grow
+	if tree.Age < StumpRegrowTime {
+		return
+	}
+	tree.Stump = false
+	tree.Hits = 0
+	tree.Stage = StageSapling
+	tree.Age = stageStart(StageSapling)
+	treesLog.Info("stump sprouted", "pos", tree.Position)
+}
@@ -0,0 +1,381 @@
+package sim
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+
+	"main/tilemap"
+)
+
+// A generated world is loaded a chunk at a time, in the map's chunks of
+// tilemap.ChunkSize tiles square. Chunks within ChunkLoadRadius of the
+// player's chunk are loaded, and those beyond ChunkUnloadRadius are
+// unloaded; the gap keeps a player pacing over a chunk border from loading
+// and unloading the same chunks over and over. Both are counted in chunks.
+const (
+	ChunkLoadRadius   = 2
+	ChunkUnloadRadius = 3
+)
+
+// SavedChunk is a chunk of a generated world that differs from what the
+// generator makes there, or that was loaded when the world was saved. On a
+// hand-made map it's a chunk whose tiles were edited.
+type SavedChunk struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+
+	// Loaded chunks' trees and items are saved with the rest of the world's,
+	// so only their tiles are kept here.
+	Loaded bool `json:"loaded,omitempty"`
+
+	Tiles      map[string]*tilemap.Chunk `json:"tiles,omitempty"` // By layer, if the tiles were changed
+	Trees      []SavedTree               `json:"trees,omitempty"`
+	WorldItems []WorldItem               `json:"worldItems,omitempty"`
+}
+
+func (c SavedChunk) coord() tilemap.ChunkCoord {
+	return tilemap.ChunkCoord{X: c.X, Y: c.Y}
+}
+
+// Generated reports whether the world's map is generated as it's explored.
+func (w *World) Generated() bool {
+	return w.gen != nil
+}
+
+// Biome returns the biome of a generated world at tile x, y.
+func (w *World) Biome(x, y int) (Biome, bool) {
+	if w.gen == nil {
+		return 0, false
+	}
+	return w.gen.biome(x, y), true
+}
+
+// LoadedChunks returns how many chunks of a generated world are loaded and
+// how many unloaded ones are kept because they were changed.
+func (w *World) LoadedChunks() (loaded, stored int) {
+	if w.gen == nil {
+		return 0, 0
+	}
+	return len(w.gen.loaded), len(w.gen.stored)
+}
+
+// chunkAt returns the chunk holding world position pos.
+func (w *World) chunkAt(pos Vec2) tilemap.ChunkCoord {
+	tileW, tileH := w.Config.Map.TileSize()
+	return tilemap.ChunkOf(int(math.Floor(float64(pos.X/tileW))), int(math.Floor(float64(pos.Y/tileH))))
+}
+
+// updateChunks loads the chunks around the player and unloads those left
+// far behind. Chunks load nearest first, and unload in order, so the world
+// changes the same way whenever the player takes the same path.
+func (w *World) updateChunks() {
+	if w.gen == nil {
+		return
+	}
+	center := w.chunkAt(w.Player.Center())
+
+	var far []tilemap.ChunkCoord
+	for c := range w.gen.loaded {
+		if chunkDistance(c, center) > ChunkUnloadRadius {
+			far = append(far, c)
+		}
+	}
+	slices.SortFunc(far, compareChunks)
+	for _, c := range far {
+		w.unloadChunk(c)
+	}
+
+	for r := 0; r <= ChunkLoadRadius; r++ {
+		for y := center.Y - r; y <= center.Y+r; y++ {
+			for x := center.X - r; x <= center.X+r; x++ {
+				c := tilemap.ChunkCoord{X: x, Y: y}
+				if chunkDistance(c, center) == r && !w.gen.loaded[c] {
+					w.loadChunk(c)
+				}
+			}
+		}
+	}
+}
+
+// chunkDistance is how many chunks apart a and b are, diagonals counting
+// as one.
+func chunkDistance(a, b tilemap.ChunkCoord) int {
+	return max(abs(a.X-b.X), abs(a.Y-b.Y))
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func compareChunks(a, b tilemap.ChunkCoord) int {
+	return cmp.Or(cmp.Compare(a.Y, b.Y), cmp.Compare(a.X, b.X))
+}
+
+// loadChunk generates chunk c, then puts back whatever had been changed
+// there when it was unloaded.
+func (w *World) loadChunk(c tilemap.ChunkCoord) {
+	g := w.gen
+	g.generateTiles(c)
+	g.loaded[c] = true
+
+	saved, ok := g.stored[c]
+	if !ok {
+		for _, tree := range g.generateTrees(c, w.treeSeed()) {
+			w.addTree(tree)
+		}
+		return
+	}
+	delete(g.stored, c)
+	w.restoreTiles(saved)
+	for _, tree := range saved.Trees {
+		w.addTree(tree.tree())
+	}
+	for _, item := range saved.WorldItems {
+		w.addWorldItem(item.restored())
+	}
+}
+
+// restoreTiles puts back the tiles of a chunk that was changed.
+func (w *World) restoreTiles(saved SavedChunk) {
+	if saved.Tiles == nil {
+		return
+	}
+	c := saved.coord()
+	for _, l := range w.Config.Map.Layers {
+		var chunk *tilemap.Chunk
+		if tiles := saved.Tiles[l.Name]; tiles != nil {
+			copied := *tiles
+			chunk = &copied
+		}
+		l.SetChunk(c, chunk)
+	}
+	w.gen.edited[c] = true
+}
+
+// unloadChunk takes chunk c's tiles, trees and items out of the world,
+// keeping them if they differ from what the generator would make again.
+func (w *World) unloadChunk(c tilemap.ChunkCoord) {
+	g := w.gen
+	saved := w.saveChunk(c)
+
+	w.removeTrees(w.chunkTrees(c))
+	// Last first, so the items that take the removed ones' places are never
+	// ones still to go.
+	for _, i := range slices.Backward(w.chunkItems(c)) {
+		w.removeWorldItem(i)
+	}
+	for _, l := range w.Config.Map.Layers {
+		l.SetChunk(c, nil)
+	}
+	delete(g.loaded, c)
+	delete(g.edited, c)
+
+	if saved.Tiles != nil || len(saved.WorldItems) > 0 || !w.treesAsGenerated(c, saved.Trees) {
+		g.stored[c] = saved
+	}
+}
+
+// saveChunk captures loaded chunk c: its tiles if they were changed, and
+// its trees and items.
+func (w *World) saveChunk(c tilemap.ChunkCoord) SavedChunk {
+	saved := SavedChunk{X: c.X, Y: c.Y}
+	if w.gen.edited[c] {
+		saved.Tiles = w.copyTiles(c)
+	}
+	for _, i := range w.chunkTrees(c) {
+		saved.Trees = append(saved.Trees, w.Trees[i].saved())
+	}
+	for _, i := range w.chunkItems(c) {
+		saved.WorldItems = append(saved.WorldItems, w.WorldItems[i].clone())
+	}
+	return saved
+}
+
+// chunkTrees returns the indices in Trees of the trees in chunk c, in order.
+func (w *World) chunkTrees(c tilemap.ChunkCoord) []int {
+	return slices.DeleteFunc(w.TreesIn(w.chunkArea(c)), func(i int) bool { return w.chunkAt(w.Trees[i].Position) != c })
+}
+
+// chunkItems returns the indices in WorldItems of the items in chunk c, in
+// order.
+func (w *World) chunkItems(c tilemap.ChunkCoord) []int {
+	return slices.DeleteFunc(w.ItemsIn(w.chunkArea(c)), func(i int) bool { return w.chunkAt(w.WorldItems[i].Position) != c })
+}
+
+// chunkArea returns the ground chunk c covers, with a pixel to spare all
+// round so rounding never leaves anything at its edges out. Whatever's in
+// the spare pixels belongs to the chunks next to it.
+func (w *World) chunkArea(c tilemap.ChunkCoord) Rect {
+	tileW, tileH := w.Config.Map.TileSize()
+	width, height := tilemap.ChunkSize*tileW, tilemap.ChunkSize*tileH
+	return Rect{float32(c.X)*width - 1, float32(c.Y)*height - 1, width + 2, height + 2}
+}
+
+// treesAsGenerated reports whether trees are the ones the generator grows in
+// chunk c, still standing. How long they've been growing and dropping seeds
+// doesn't count.
+func (w *World) treesAsGenerated(c tilemap.ChunkCoord, trees []SavedTree) bool {
+	generated := w.gen.generateTrees(c, w.treeSeed())
+	if len(trees) != len(generated) {
+		return false
+	}
+	for i, tree := range trees {
+		gen := generated[i]
+		if tree.Position != gen.Position || tree.Seed != gen.Seed || tree.Stage != gen.Stage || tree.Stump || tree.Hits != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// copyTiles copies chunk c of every layer that has tiles there, by layer.
+func (w *World) copyTiles(c tilemap.ChunkCoord) map[string]*tilemap.Chunk {
+	tiles := make(map[string]*tilemap.Chunk)
+	for _, l := range w.Config.Map.Layers {
+		if chunk := l.Chunk(c); chunk != nil {
+			copied := *chunk
+			tiles[l.Name] = &copied
+		}
+	}
+	return tiles
+}
+
+// putTiles replaces chunk c of every layer with a copy of its tiles in
+// tiles, exactly, clearing the layers that have none there.
+func (w *World) putTiles(c tilemap.ChunkCoord, tiles map[string]*tilemap.Chunk) {
+	for _, l := range w.Config.Map.Layers {
+		var chunk *tilemap.Chunk
+		if t := tiles[l.Name]; t != nil {
+			copied := *t
+			chunk = &copied
+		}
+		l.RestoreChunk(c, chunk)
+	}
+}
+
+// saveChunks lists the loaded chunks and the changed unloaded ones, in
+// order so the same world always saves the same. On a hand-made map it lists
+// the edited chunks.
+func (w *World) saveChunks() []SavedChunk {
+	if w.gen == nil {
+		var chunks []SavedChunk
+		for _, c := range slices.SortedFunc(maps.Keys(w.mapEdits), compareChunks) {
+			chunks = append(chunks, SavedChunk{X: c.X, Y: c.Y, Tiles: w.copyTiles(c)})
+		}
+		return chunks
+	}
+	var chunks []SavedChunk
+	for _, c := range slices.SortedFunc(maps.Keys(w.gen.loaded), compareChunks) {
+		saved := SavedChunk{X: c.X, Y: c.Y, Loaded: true}
+		if w.gen.edited[c] {
+			saved.Tiles = w.saveChunk(c).Tiles
+		}
+		chunks = append(chunks, saved)
+	}
+	for _, c := range slices.SortedFunc(maps.Keys(w.gen.stored), compareChunks) {
+		chunks = append(chunks, w.gen.stored[c])
+	}
+	return chunks
+}
+
+// loadChunks rebuilds a generated world's chunks from a save. The loaded
+// chunks' trees and items come back with the rest of the world's, so only
+// their tiles are made here.
+func (w *World) loadChunks(chunks []SavedChunk) {
+	if w.gen == nil {
+		w.loadMapEdits(chunks)
+		return
+	}
+	w.gen.reseed(w.Config.Seed)
+	for _, saved := range chunks {
+		c := saved.coord()
+		if !saved.Loaded {
+			w.gen.stored[c] = saved
+			continue
+		}
+		w.gen.generateTiles(c)
+		w.gen.loaded[c] = true
+		w.restoreTiles(saved)
+	}
+}
+
+// loadMapEdits puts the edited chunks of a hand-made map back as they were
+// before any edits, then puts in the ones from a save.
+func (w *World) loadMapEdits(chunks []SavedChunk) {
+	if w.Config.Map == nil {
+		return
+	}
+	for c, tiles := range w.mapEdits {
+		w.putTiles(c, tiles)
+	}
+	clear(w.mapEdits)
+	for _, saved := range chunks {
+		c := saved.coord()
+		if _, ok := w.mapEdits[c]; !ok {
+			w.mapEdits[c] = w.copyTiles(c)
+		}
+		w.putTiles(c, saved.Tiles)
+	}
+}
+
+// keepMapEdit remembers how the chunks around tile x, y of a hand-made map
+// looked before they were first edited. Editing a tile refits the eight
+// around it, which can be in the neighboring chunks.
+func (w *World) keepMapEdit(x, y int) {
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			c := tilemap.ChunkOf(x+dx, y+dy)
+			if _, ok := w.mapEdits[c]; !ok {
+				w.mapEdits[c] = w.copyTiles(c)
+			}
+		}
+	}
+}
+
+// PaintTile paints the cell at tile x, y with the terrain of the named
+// layer, as Layer.Paint does. In a generated world the chunk must be loaded,
+// and it's kept from then on. Edits to a hand-made map are saved too.
+func (w *World) PaintTile(layer string, x, y int) error {
+	l, err := w.editTile(layer, x, y)
+	if err != nil {
+		return err
+	}
+	return l.Paint(x, y)
+}
+
+// EraseTile clears the cell at tile x, y of the named layer, as Layer.Erase
+// does.
+func (w *World) EraseTile(layer string, x, y int) error {
+	l, err := w.editTile(layer, x, y)
+	if err != nil {
+		return err
+	}
+	l.Erase(x, y)
+	return nil
+}
+
+func (w *World) editTile(layer string, x, y int) (*tilemap.Layer, error) {
+	if w.Config.Map == nil {
+		return nil, fmt.Errorf("no map is loaded")
+	}
+	l := w.Config.Map.Layer(layer)
+	if l == nil {
+		return nil, fmt.Errorf("no layer %q", layer)
+	}
+	if w.gen != nil {
+		c := tilemap.ChunkOf(x, y)
+		if !w.gen.loaded[c] {
+			return nil, fmt.Errorf("tile %d, %d is in a chunk that isn't loaded", x, y)
+		}
+		w.gen.edited[c] = true
+	} else {
+		w.keepMapEdit(x, y)
+	}
+	return l, nil
+}
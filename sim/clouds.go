@@ -0,0 +1,47 @@
+package sim
+
+func (w *World) initClouds() {
+	// Layer 1: Farthest, fewest clouds, highest Y, slowest
+	w.CloudsLayer1 = []Vec2{
+		{X: 100, Y: 80},
+		{X: 700, Y: 120},
+		{X: 1400, Y: 60},
+	}
+	// Layer 2: Middle
+	w.CloudsLayer2 = []Vec2{
+		{X: 300, Y: 200},
+		{X: 900, Y: 180},
+		{X: 1600, Y: 220},
+	}
+	// Layer 3: Closest, lowest Y, fastest
+	w.CloudsLayer3 = []Vec2{
+		{X: 50, Y: 320},
+		{X: 800, Y: 350},
+		{X: 1500, Y: 300},
+	}
+}
+
+// Cloud layer speeds, in pixels per second.
+const (
+	cloudSpeed1 float32 = 12
+	cloudSpeed2 float32 = 30
+	cloudSpeed3 float32 = 60
+)
+
+func (w *World) updateClouds(dt float32) {
+	// Layer 1: slowest
+	w.scrollClouds(w.CloudsLayer1, cloudSpeed1*dt)
+	// Layer 2: medium
+	w.scrollClouds(w.CloudsLayer2, cloudSpeed2*dt)
+	// Layer 3: fastest
+	w.scrollClouds(w.CloudsLayer3, cloudSpeed3*dt)
+}
+
+func (w *World) scrollClouds(layer []Vec2, step float32) {
+	for i := range layer {
+		layer[i].X += step
+		if layer[i].X > w.Config.ViewWidth {
+			layer[i].X = -w.Config.CloudWidth
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package sim
+
+import (
+	"math"
+
+	"main/tilemap"
+)
+
+// Footprints are the parts of things that block movement, as boxes relative
+// to where each thing stands. They cover what touches the ground, such as a
+// tree's trunk, rather than the whole sprite, so the player can walk behind
+// a canopy.
+var (
+	// PlayerFootprint is relative to the player's center.
+	PlayerFootprint = Rect{-14, 8, 28, 14}
+
+	// TreeFootprints are relative to the base of the trunk. Seedlings don't
+	// block at all.
+	TreeFootprints = [TreeStages]Rect{
+		StageSeedling: {},
+		StageSapling:  {-6, -8, 12, 8},
+		StageYoung:    {-10, -12, 20, 12},
+		StageMature:   {-16, -18, 32, 18},
+	}
+	StumpFootprint = Rect{-16, -16, 32, 16}
+)
+
+// Prop is a fixed piece of scenery, drawn with the texture named by Kind.
+type Prop struct {
+	Kind      string
+	Position  Vec2 // Middle of the bottom edge of the sprite
+	Footprint Rect // Relative to Position
+}
+
+// StartingProps are the props every world starts with.
+var StartingProps = []Prop{
+	{Kind: "nest", Position: Vec2{409, 436}, Footprint: Rect{-60, -86, 115, 50}},
+}
+
+// Overlaps reports whether two boxes overlap. Boxes that only touch don't.
+func (r Rect) Overlaps(o Rect) bool {
+	return r.X < o.X+o.Width && o.X < r.X+r.Width &&
+		r.Y < o.Y+o.Height && o.Y < r.Y+r.Height
+}
+
+// Empty reports whether the box has no area.
+func (r Rect) Empty() bool {
+	return r.Width <= 0 || r.Height <= 0
+}
+
+// offset returns the box moved to be relative to pos.
+func (r Rect) offset(pos Vec2) Rect {
+	return Rect{pos.X + r.X, pos.Y + r.Y, r.Width, r.Height}
+}
+
+// Footprint returns the player's footprint in world coordinates.
+func (p *Player) Footprint() Rect {
+	return PlayerFootprint.offset(p.Center())
+}
+
+// Footprint returns the tree's footprint in world coordinates, which is
+// empty for trees that don't block.
+func (t *Tree) Footprint() Rect {
+	if t.Stump {
+		return StumpFootprint.offset(t.Position)
+	}
+	return TreeFootprints[t.Stage].offset(t.Position)
+}
+
+// Obstacles returns every box that blocks movement and overlaps area: solid
+// map tiles, trees and props.
+func (w *World) Obstacles(area Rect) []Rect {
+	var boxes []Rect
+	add := func(box Rect) {
+		if !box.Empty() && box.Overlaps(area) {
+			boxes = append(boxes, box)
+		}
+	}
+
+	if m := w.Config.Map; m != nil {
+		tileW, tileH := m.TileSize()
+		x0 := int(math.Floor(float64(area.X / tileW)))
+		y0 := int(math.Floor(float64(area.Y / tileH)))
+		x1 := int(math.Floor(float64((area.X + area.Width) / tileW)))
+		y1 := int(math.Floor(float64((area.Y + area.Height) / tileH)))
+		for y := y0; y <= y1; y++ {
+			for x := x0; x <= x1; x++ {
+				if m.Flag(x, y, tilemap.PropSolid) {
+					add(Rect{float32(x) * tileW, float32(y) * tileH, tileW, tileH})
+				}
+			}
+		}
+	}
+	// A tree can only block area if it stands within its biggest footprint
+	// of it.
+	reach := treeFootprintBounds()
+	near := Rect{
+		X:      area.X - reach.X - reach.Width - 1,
+		Y:      area.Y - reach.Y - reach.Height - 1,
+		Width:  area.Width + reach.Width + 2,
+		Height: area.Height + reach.Height + 2,
+	}
+	for key := range w.treeIndex().QueryRect(near) {
+		add(w.Trees[w.treeAt(key)].Footprint())
+	}
+	for _, prop := range w.Props {
+		add(prop.Footprint.offset(prop.Position))
+	}
+	return boxes
+}
+
+// moveBox moves box by delta, stopping it against obstacles. Each axis is
+// resolved on its own, so a box pushed diagonally into a wall slides along
+// it. Obstacles the box already overlaps are ignored, so something that
+// grows or lands on top of the player can't trap them.
+func (w *World) moveBox(box Rect, delta Vec2) Rect {
+	stuck := w.Obstacles(box)
+	blocks := func(o Rect) bool {
+		for _, s := range stuck {
+			if s == o {
+				return false
+			}
+		}
+		return true
+	}
+
+	if delta.X != 0 {
+		box.X += delta.X
+		for _, o := range w.Obstacles(box) {
+			switch {
+			case !blocks(o):
+			case delta.X > 0:
+				box.X = min(box.X, o.X-box.Width)
+			default:
+				box.X = max(box.X, o.X+o.Width)
+			}
+		}
+	}
+	if delta.Y != 0 {
+		box.Y += delta.Y
+		for _, o := range w.Obstacles(box) {
+			switch {
+			case !blocks(o):
+			case delta.Y > 0:
+				box.Y = min(box.Y, o.Y-box.Height)
+			default:
+				box.Y = max(box.Y, o.Y+o.Height)
+			}
+		}
+	}
+	return box
+}
+
+// treeFootprintBounds returns the box around every tree footprint, relative
+// to the base of the trunk.
+func treeFootprintBounds() Rect {
+	bounds := StumpFootprint
+	for _, fp := range TreeFootprints {
+		if fp.Empty() {
+			continue
+		}
+		x0, y0 := min(bounds.X, fp.X), min(bounds.Y, fp.Y)
+		x1 := max(bounds.X+bounds.Width, fp.X+fp.Width)
+		y1 := max(bounds.Y+bounds.Height, fp.Y+fp.Height)
+		bounds = Rect{x0, y0, x1 - x0, y1 - y0}
+	}
+	return bounds
+}
@@ -0,0 +1,255 @@
+package sim
+
+import (
+	"fmt"
+	"strconv"
+
+	"main/console"
+)
+
+// RegisterCommands adds the world's console commands to r. world returns the
+// world to act on, or an error if it mustn't be changed right now; items
+// names what give accepts.
+func RegisterCommands(r *console.Registry, items *ItemRegistry, world func() (*World, error)) {
+	r.Register(console.Command{
+		Name: "give",
+		Args: "<item> [count]",
+		Help: "add items to the inventory; a negative count takes them away",
+		Run: func(args []string) (string, error) {
+			if len(args) < 1 || len(args) > 2 {
+				return "", console.ErrUsage
+			}
+			item, err := items.Parse(args[0])
+			if err != nil {
+				return "", err
+			}
+			count := 1
+			if len(args) == 2 {
+				if count, err = strconv.Atoi(args[1]); err != nil {
+					return "", console.ErrUsage
+				}
+			}
+
+			w, err := world()
+			if err != nil {
+				return "", err
+			}
+			if err := w.GiveItem(item.ID, count); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("gave %d %s", count, item.ID), nil
+		},
+		Complete: func(args []string) []string {
+			if len(args) == 1 {
+				return items.IDs()
+			}
+			return nil
+		},
+	})
+
+	r.Register(console.Command{
+		Name: "spawn",
+		Args: "tree <x> <y> [stage] | item <item> <x> <y> [count]",
+		Help: "plant a tree or drop items; stage is seedling, sapling, young, mature or 0-3",
+		Run: func(args []string) (string, error) {
+			if len(args) == 0 {
+				return "", console.ErrUsage
+			}
+			switch args[0] {
+			case "tree":
+				return spawnTree(world, args[1:])
+			case "item":
+				return spawnItem(world, items, args[1:])
+			}
+			return "", console.ErrUsage
+		},
+		Complete: func(args []string) []string {
+			switch {
+			case len(args) == 1:
+				return []string{"tree", "item"}
+			case args[0] == "tree" && len(args) == 4:
+				return treeStageNames[:]
+			case args[0] == "item" && len(args) == 2:
+				return items.IDs()
+			}
+			return nil
+		},
+	})
+
+	r.Register(console.Command{
+		Name: "inventory",
+		Args: "move <from> <to> | split <slot>",
+		Help: "rearrange the bag; slots count from 1",
+		Run: func(args []string) (string, error) {
+			if len(args) < 2 {
+				return "", console.ErrUsage
+			}
+			slots := make([]int, len(args)-1)
+			for i, arg := range args[1:] {
+				n, err := strconv.Atoi(arg)
+				if err != nil {
+					return "", console.ErrUsage
+				}
+				slots[i] = n - 1
+			}
+
+			w, err := world()
+			if err != nil {
+				return "", err
+			}
+			switch {
+			case args[0] == "move" && len(slots) == 2:
+				if err := w.Inventory.Move(slots[0], slots[1]); err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("moved slot %s to %s", args[1], args[2]), nil
+			case args[0] == "split" && len(slots) == 1:
+				if err := w.Inventory.Split(slots[0]); err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("split slot %s", args[1]), nil
+			}
+			return "", console.ErrUsage
+		},
+		Complete: func(args []string) []string {
+			if len(args) == 1 {
+				return []string{"move", "split"}
+			}
+			return nil
+		},
+	})
+
+	r.Register(console.Command{
+		Name: "teleport",
+		Args: "<x> <y>",
+		Help: "move the player to a world position",
+		Run: func(args []string) (string, error) {
+			if len(args) != 2 {
+				return "", console.ErrUsage
+			}
+			pos, err := parseVec2(args[0], args[1])
+			if err != nil {
+				return "", err
+			}
+
+			w, err := world()
+			if err != nil {
+				return "", err
+			}
+			w.Player.Teleport(pos)
+			return fmt.Sprintf("teleported to %.0f, %.0f", pos.X, pos.Y), nil
+		},
+	})
+
+	r.Register(console.Command{
+		Name: "set",
+		Args: "growthspeed <multiplier>",
+		Help: "change a world setting; growthspeed 10 makes trees grow ten times faster",
+		Run: func(args []string) (string, error) {
+			if len(args) != 2 || args[0] != "growthspeed" {
+				return "", console.ErrUsage
+			}
+			speed, err := strconv.ParseFloat(args[1], 32)
+			if err != nil || speed <= 0 {
+				return "", fmt.Errorf("growth speed must be a positive number")
+			}
+
+			w, err := world()
+			if err != nil {
+				return "", err
+			}
+			w.GrowthSpeed = float32(speed)
+			return fmt.Sprintf("growth speed is %g", speed), nil
+		},
+		Complete: func(args []string) []string {
+			if len(args) == 1 {
+				return []string{"growthspeed"}
+			}
+			return nil
+		},
+	})
+
+	r.Register(console.Command{
+		Name: "clear",
+		Args: "particles",
+		Help: "remove every live particle",
+		Run: func(args []string) (string, error) {
+			if len(args) != 1 || args[0] != "particles" {
+				return "", console.ErrUsage
+			}
+
+			w, err := world()
+			if err != nil {
+				return "", err
+			}
+			n := len(w.Particles)
+			w.ClearParticles()
+			return fmt.Sprintf("cleared %d particles", n), nil
+		},
+		Complete: func(args []string) []string {
+			if len(args) == 1 {
+				return []string{"particles"}
+			}
+			return nil
+		},
+	})
+}
+
+func spawnTree(world func() (*World, error), args []string) (string, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return "", console.ErrUsage
+	}
+	pos, err := parseVec2(args[0], args[1])
+	if err != nil {
+		return "", err
+	}
+	stage := StageSeedling
+	if len(args) == 3 {
+		if stage, err = ParseTreeStage(args[2]); err != nil {
+			return "", err
+		}
+	}
+
+	w, err := world()
+	if err != nil {
+		return "", err
+	}
+	w.SpawnTree(pos, stage)
+	return fmt.Sprintf("spawned %s tree at %.0f, %.0f", stage, pos.X, pos.Y), nil
+}
+
+func spawnItem(world func() (*World, error), items *ItemRegistry, args []string) (string, error) {
+	if len(args) < 3 || len(args) > 4 {
+		return "", console.ErrUsage
+	}
+	def, err := items.Parse(args[0])
+	if err != nil {
+		return "", err
+	}
+	pos, err := parseVec2(args[1], args[2])
+	if err != nil {
+		return "", err
+	}
+	count := 1
+	if len(args) == 4 {
+		if count, err = strconv.Atoi(args[3]); err != nil || count <= 0 {
+			return "", fmt.Errorf("count must be a positive whole number")
+		}
+	}
+
+	w, err := world()
+	if err != nil {
+		return "", err
+	}
+	w.SpawnItem(def.ID, count, pos)
+	return fmt.Sprintf("spawned %d %s at %.0f, %.0f", count, def.ID, pos.X, pos.Y), nil
+}
+
+func parseVec2(x, y string) (Vec2, error) {
+	fx, errX := strconv.ParseFloat(x, 32)
+	fy, errY := strconv.ParseFloat(y, 32)
+	if errX != nil || errY != nil {
+		return Vec2{}, fmt.Errorf("position %s %s is not two numbers", x, y)
+	}
+	return Vec2{float32(fx), float32(fy)}, nil
+}
@@ -0,0 +1,99 @@
+package sim
+
+import "math"
+
+const (
+	// BoostRadius is how close a crystal has to lie to a tree's base to
+	// speed up its growth.
+	BoostRadius float32 = 200
+
+	// MaxBoost is the most crystals can add to a tree's growth rate. Each
+	// crystal closes BoostFalloff of the gap left to it, so the first adds
+	// half, the second a quarter, and so on.
+	MaxBoost     float32 = 2
+	BoostFalloff float32 = 0.5
+
+	// SparkleInterval is how often a tree with a boost of 1 sparkles, in
+	// seconds; stronger boosts sparkle more often.
+	SparkleInterval float32 = 0.4
+)
+
+// sparkleColors are picked from at random for each sparkle.
+var sparkleColors = []Color{
+	{255, 250, 180, 255},
+	{180, 240, 255, 255},
+	{255, 255, 255, 255},
+}
+
+// treeBoost returns the growth multiplier crystals give tree. It's worked
+// out again only when crystals around the tree have changed, or the item
+// index was rebuilt, since the last time.
+func (w *World) treeBoost(tree *Tree) float32 {
+	w.itemIndex()
+	if tree.boostVersion != w.items.version {
+		tree.boost = w.growthBoost(tree.Position)
+		tree.boostVersion = w.items.version
+	}
+	return tree.boost
+}
+
+// crystalChanged has the trees around pos work out their boost again if
+// item is a crystal lying at rest, where it counts toward their boost.
+// While the item index is stale there's nothing to do: rebuilding it has
+// every tree start over.
+func (w *World) crystalChanged(item *WorldItem, pos Vec2) {
+	if item.Motion != nil || !w.items.live() {
+		return
+	}
+	if def, ok := w.Config.Items.Get(item.Item); !ok || !def.HasTag(TagCrystal) {
+		return
+	}
+	// A pixel to spare, so rounding never leaves a tree at the edge out
+	for key := range w.treeIndex().QueryRadius(pos, BoostRadius+1) {
+		w.Trees[w.treeAt(key)].boostVersion = 0
+	}
+}
+
+// growthBoost returns the growth multiplier crystals lying around pos give,
+// from 1 with none up toward 1+MaxBoost.
+func (w *World) growthBoost(pos Vec2) float32 {
+	crystals := 0
+	for key := range w.itemIndex().QueryRadius(pos, BoostRadius) {
+		item := &w.WorldItems[w.itemAt(key)]
+		if item.Motion != nil {
+			continue
+		}
+		if def, ok := w.Config.Items.Get(item.Item); ok && def.HasTag(TagCrystal) {
+			crystals += item.Count
+		}
+	}
+	if crystals == 0 {
+		return 1
+	}
+	return 1 + MaxBoost*(1-float32(math.Pow(float64(1-BoostFalloff), float64(crystals))))
+}
+
+// sparkle gives a boosted tree the odd glint somewhere over its crown.
+func (w *World) sparkle(tree *Tree, dt float32) {
+	tree.SparkleTime += dt * (tree.Boost - 1)
+	for tree.SparkleTime >= SparkleInterval {
+		tree.SparkleTime -= SparkleInterval
+
+		rng := w.RNG(StreamParticles)
+		height := 40 + 60*float32(tree.Stage+1)
+		pos := Vec2{
+			X: tree.Position.X + (float32(rng.Float64())-0.5)*60,
+			Y: tree.Position.Y - float32(rng.Float64())*height,
+		}
+		w.Particles = append(w.Particles, Particle{
+			Position:     pos,
+			PrevPosition: pos,
+			Velocity:     Vec2{X: (float32(rng.Float64()) - 0.5) * 20, Y: -30},
+			Gravity:      -20, // Drifts up
+			Color:        sparkleColors[rng.Intn(len(sparkleColors))],
+			Size:         float32(1.5 + rng.Float64()*2),
+			Life:         1,
+			MaxLife:      1,
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package sim
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"hash/fnv"
+	"math"
+)
+
+// Hash fingerprints the simulated state: everything that is saved, plus the
+// live particles. Replays compare hashes to catch the moment a run stops
+// matching its recording.
+func (w *World) Hash() uint64 {
+	h := fnv.New64a()
+
+	// Save data is plain structs, slices and maps, which encoding/json writes
+	// with sorted keys, so it always encodes the same.
+	data, _ := json.Marshal(w.Save())
+	h.Write(data)
+
+	var buf [4]byte
+	putFloat := func(f float32) {
+		binary.LittleEndian.PutUint32(buf[:], math.Float32bits(f))
+		h.Write(buf[:])
+	}
+	for _, p := range w.Particles {
+		putFloat(p.Position.X)
+		putFloat(p.Position.Y)
+		putFloat(p.Velocity.X)
+		putFloat(p.Velocity.Y)
+		putFloat(p.Life)
+	}
+	return h.Sum64()
+}
@@ -0,0 +1,216 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+)
+
+// InventorySize is how many slots the player's bag has.
+const InventorySize = 4
+
+// ErrInventoryFull is returned when items don't fit in an inventory.
+var ErrInventoryFull = errors.New("inventory is full")
+
+// InventorySlot holds a stack of one item. An empty slot has no Item and a
+// zero Count.
+type InventorySlot struct {
+	Item  ItemID `json:"item,omitempty"`
+	Count int    `json:"count,omitempty"`
+}
+
+// Empty reports whether the slot holds nothing.
+func (s InventorySlot) Empty() bool {
+	return s.Count <= 0
+}
+
+// Inventory is a fixed number of slots, each holding a stack of up to the
+// item's MaxStack, plus which slot the player has selected.
+type Inventory struct {
+	Slots    []InventorySlot
+	Selected int
+
+	items *ItemRegistry
+}
+
+// NewInventory returns an empty inventory with size slots, taking stack
+// sizes from items.
+func NewInventory(size int, items *ItemRegistry) Inventory {
+	return Inventory{Slots: make([]InventorySlot, size), items: items}
+}
+
+func (inv *Inventory) maxStack(id ItemID) (int, error) {
+	def, ok := inv.items.Get(id)
+	if !ok {
+		return 0, fmt.Errorf("unknown item %q", id)
+	}
+	return def.MaxStack, nil
+}
+
+// AddItem puts count of an item into the inventory, topping up existing
+// stacks before starting new ones in empty slots. It returns how many didn't
+// fit, along with ErrInventoryFull if that isn't zero.
+func (inv *Inventory) AddItem(id ItemID, count int) (int, error) {
+	maxStack, err := inv.maxStack(id)
+	if err != nil {
+		return count, err
+	}
+
+	for i := range inv.Slots {
+		slot := &inv.Slots[i]
+		if count == 0 {
+			break
+		}
+		if slot.Item == id && !slot.Empty() {
+			n := min(count, maxStack-slot.Count)
+			if n > 0 {
+				slot.Count += n
+				count -= n
+			}
+		}
+	}
+	for i := range inv.Slots {
+		slot := &inv.Slots[i]
+		if count == 0 {
+			break
+		}
+		if slot.Empty() {
+			n := min(count, maxStack)
+			*slot = InventorySlot{Item: id, Count: n}
+			count -= n
+		}
+	}
+
+	if count > 0 {
+		return count, ErrInventoryFull
+	}
+	return 0, nil
+}
+
+// RemoveItem takes up to count of an item out of the inventory, emptying the
+// last stacks first, and returns how many it took.
+func (inv *Inventory) RemoveItem(id ItemID, count int) int {
+	taken := 0
+	for i := len(inv.Slots) - 1; i >= 0 && taken < count; i-- {
+		if inv.Slots[i].Item == id {
+			taken += inv.RemoveAt(i, count-taken).Count
+		}
+	}
+	return taken
+}
+
+// RemoveAt takes up to count items out of slot i and returns what it took.
+func (inv *Inventory) RemoveAt(i, count int) InventorySlot {
+	if i < 0 || i >= len(inv.Slots) || inv.Slots[i].Empty() {
+		return InventorySlot{}
+	}
+	slot := &inv.Slots[i]
+	n := min(count, slot.Count)
+	taken := InventorySlot{Item: slot.Item, Count: n}
+	slot.Count -= n
+	if slot.Empty() {
+		*slot = InventorySlot{}
+	}
+	return taken
+}
+
+// Move moves the stack in slot from onto slot to. Stacks of the same item
+// merge as far as the stack size allows; different items swap places.
+func (inv *Inventory) Move(from, to int) error {
+	if err := inv.checkSlot(from); err != nil {
+		return err
+	}
+	if err := inv.checkSlot(to); err != nil {
+		return err
+	}
+	if from == to {
+		return nil
+	}
+
+	src, dst := &inv.Slots[from], &inv.Slots[to]
+	if src.Empty() || dst.Empty() || src.Item != dst.Item {
+		*src, *dst = *dst, *src
+		return nil
+	}
+
+	maxStack, err := inv.maxStack(src.Item)
+	if err != nil {
+		return err
+	}
+	n := min(src.Count, maxStack-dst.Count)
+	dst.Count += n
+	inv.RemoveAt(from, n)
+	return nil
+}
+
+// Split moves half of the stack in slot i, rounded down, into the first
+// empty slot.
+func (inv *Inventory) Split(i int) error {
+	if err := inv.checkSlot(i); err != nil {
+		return err
+	}
+	if inv.Slots[i].Count < 2 {
+		return fmt.Errorf("slot %d has nothing to split", i+1)
+	}
+
+	for j := range inv.Slots {
+		if inv.Slots[j].Empty() {
+			inv.Slots[j] = inv.RemoveAt(i, inv.Slots[i].Count/2)
+			return nil
+		}
+	}
+	return ErrInventoryFull
+}
+
+// Room returns how many more of an item would fit.
+func (inv *Inventory) Room(id ItemID) int {
+	maxStack, err := inv.maxStack(id)
+	if err != nil {
+		return 0
+	}
+	room := 0
+	for _, slot := range inv.Slots {
+		switch {
+		case slot.Empty():
+			room += maxStack
+		case slot.Item == id:
+			room += max(maxStack-slot.Count, 0)
+		}
+	}
+	return room
+}
+
+// Count returns how many of an item the inventory holds across all stacks.
+func (inv *Inventory) Count(id ItemID) int {
+	total := 0
+	for _, slot := range inv.Slots {
+		if slot.Item == id {
+			total += slot.Count
+		}
+	}
+	return total
+}
+
+// Select makes slot i the selected one, wrapping around at either end so the
+// mouse wheel can cycle through the slots.
+func (inv *Inventory) Select(i int) {
+	n := len(inv.Slots)
+	if n == 0 {
+		return
+	}
+	inv.Selected = (i%n + n) % n
+}
+
+// SelectedSlot returns the stack in the selected slot.
+func (inv *Inventory) SelectedSlot() InventorySlot {
+	if inv.Selected < 0 || inv.Selected >= len(inv.Slots) {
+		return InventorySlot{}
+	}
+	return inv.Slots[inv.Selected]
+}
+
+func (inv *Inventory) checkSlot(i int) error {
+	if i < 0 || i >= len(inv.Slots) {
+		return fmt.Errorf("no slot %d; slots run from 1 to %d", i+1, len(inv.Slots))
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package sim
+
+import (
+	"slices"
+	"testing"
+)
+
+func testInventory(t *testing.T, slots ...InventorySlot) Inventory {
+	t.Helper()
+	inv := NewInventory(InventorySize, testItems(t))
+	copy(inv.Slots, slots)
+	return inv
+}
+
+func TestInventoryAddItem(t *testing.T) {
+	tests := []struct {
+		name     string
+		slots    []InventorySlot
+		id       ItemID
+		count    int
+		want     []InventorySlot
+		wantLeft int
+		wantErr  string
+	}{
+		{
+			name:  "empty",
+			id:    "cone",
+			count: 3,
+			want:  []InventorySlot{{"cone", 3}, {}, {}, {}},
+		},
+		{
+			name:  "tops up before new stacks",
+			slots: []InventorySlot{{}, {"cone", 4}},
+			id:    "cone",
+			count: 3,
+			want:  []InventorySlot{{"cone", 2}, {"cone", 5}, {}, {}},
+		},
+		{
+			name:  "spreads over stacks",
+			id:    "gem",
+			count: 7,
+			want:  []InventorySlot{{"gem", 3}, {"gem", 3}, {"gem", 1}, {}},
+		},
+		{
+			name:     "overflow",
+			slots:    []InventorySlot{{"axe", 1}, {"cone", 5}, {"gem", 2}},
+			id:       "gem",
+			count:    6,
+			want:     []InventorySlot{{"axe", 1}, {"cone", 5}, {"gem", 3}, {"gem", 3}},
+			wantLeft: 2,
+			wantErr:  "inventory is full",
+		},
+		{
+			name:     "unknown item",
+			id:       "rock",
+			count:    2,
+			want:     []InventorySlot{{}, {}, {}, {}},
+			wantLeft: 2,
+			wantErr:  `unknown item "rock"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv := testInventory(t, tt.slots...)
+			left, err := inv.AddItem(tt.id, tt.count)
+			if left != tt.wantLeft {
+				t.Errorf("left = %d, want %d", left, tt.wantLeft)
+			}
+			checkErr(t, err, tt.wantErr)
+			if !slices.Equal(inv.Slots, tt.want) {
+				t.Errorf("slots = %v, want %v", inv.Slots, tt.want)
+			}
+		})
+	}
+}
+
+func TestInventoryMove(t *testing.T) {
+	tests := []struct {
+		name     string
+		slots    []InventorySlot
+		from, to int
+		want     []InventorySlot
+		wantErr  string
+	}{
+		{
+			name:  "into empty slot",
+			slots: []InventorySlot{{"cone", 2}},
+			from:  0, to: 3,
+			want: []InventorySlot{{}, {}, {}, {"cone", 2}},
+		},
+		{
+			name:  "swaps different items",
+			slots: []InventorySlot{{"cone", 2}, {"axe", 1}},
+			from:  0, to: 1,
+			want: []InventorySlot{{"axe", 1}, {"cone", 2}, {}, {}},
+		},
+		{
+			name:  "merges same item",
+			slots: []InventorySlot{{"cone", 2}, {"cone", 1}},
+			from:  0, to: 1,
+			want: []InventorySlot{{}, {"cone", 3}, {}, {}},
+		},
+		{
+			name:  "merges up to the stack size",
+			slots: []InventorySlot{{"cone", 4}, {"cone", 3}},
+			from:  0, to: 1,
+			want: []InventorySlot{{"cone", 2}, {"cone", 5}, {}, {}},
+		},
+		{
+			name:  "onto itself",
+			slots: []InventorySlot{{"cone", 4}},
+			from:  0, to: 0,
+			want: []InventorySlot{{"cone", 4}, {}, {}, {}},
+		},
+		{
+			name:  "no such slot",
+			slots: []InventorySlot{{"cone", 4}},
+			from:  0, to: InventorySize,
+			want:    []InventorySlot{{"cone", 4}, {}, {}, {}},
+			wantErr: "no slot 5",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv := testInventory(t, tt.slots...)
+			checkErr(t, inv.Move(tt.from, tt.to), tt.wantErr)
+			if !slices.Equal(inv.Slots, tt.want) {
+				t.Errorf("slots = %v, want %v", inv.Slots, tt.want)
+			}
+		})
+	}
+}
+
+func TestInventorySplit(t *testing.T) {
+	tests := []struct {
+		name    string
+		slots   []InventorySlot
+		slot    int
+		want    []InventorySlot
+		wantErr string
+	}{
+		{
+			name:  "halves rounding down",
+			slots: []InventorySlot{{}, {"cone", 5}},
+			slot:  1,
+			want:  []InventorySlot{{"cone", 2}, {"cone", 3}, {}, {}},
+		},
+		{
+			name:    "single item",
+			slots:   []InventorySlot{{"axe", 1}},
+			slot:    0,
+			want:    []InventorySlot{{"axe", 1}, {}, {}, {}},
+			wantErr: "slot 1 has nothing to split",
+		},
+		{
+			name:    "no empty slot",
+			slots:   []InventorySlot{{"cone", 4}, {"gem", 1}, {"gem", 1}, {"axe", 1}},
+			slot:    0,
+			want:    []InventorySlot{{"cone", 4}, {"gem", 1}, {"gem", 1}, {"axe", 1}},
+			wantErr: "inventory is full",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv := testInventory(t, tt.slots...)
+			checkErr(t, inv.Split(tt.slot), tt.wantErr)
+			if !slices.Equal(inv.Slots, tt.want) {
+				t.Errorf("slots = %v, want %v", inv.Slots, tt.want)
+			}
+		})
+	}
+}
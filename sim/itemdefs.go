@@ -0,0 +1,129 @@
+package sim
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"slices"
+)
+
+// ItemsPath is where the item definitions live in the res tree.
+const ItemsPath = "items.json"
+
+// ItemID identifies an item type, like "pinecone".
+type ItemID string
+
+// Item tags the game logic looks for.
+const (
+	TagPlantable = "plantable" // Grows into a tree when planted
+	TagAxe       = "axe"       // Chops trees when used
+	TagCrystal   = "crystal"   // Speeds up the growth of nearby trees
+)
+
+// ItemDef describes one kind of item. Sprite and Icon name textures in the
+// asset manifest.
+type ItemDef struct {
+	ID          ItemID   `json:"id"`
+	Name        string   `json:"name"`
+	Sprite      string   `json:"sprite"`      // Drawn when the item lies in the world
+	SpriteFrame int      `json:"spriteFrame"` // Frame of Sprite, for sprite sheets
+	Icon        string   `json:"icon"`        // Drawn in the inventory
+	IconFrame   int      `json:"iconFrame"`
+	Scale       float32  `json:"scale"` // World sprite scale
+	MaxStack    int      `json:"maxStack"`
+	Start       int      `json:"start"` // How many a new world starts with
+	Tags        []string `json:"tags"`
+}
+
+// HasTag reports whether the item has the given tag.
+func (d *ItemDef) HasTag(tag string) bool {
+	return slices.Contains(d.Tags, tag)
+}
+
+// ItemRegistry holds every item definition, in file order.
+type ItemRegistry struct {
+	defs []*ItemDef
+	byID map[ItemID]*ItemDef
+}
+
+// LoadItems reads item definitions from the res tree.
+func LoadItems(fsys fs.FS) (*ItemRegistry, error) {
+	data, err := fs.ReadFile(fsys, ItemsPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading item definitions: %w", err)
+	}
+	items, err := ParseItems(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ItemsPath, err)
+	}
+	return items, nil
+}
+
+// ParseItems parses and checks item definitions. Scale defaults to 1 and
+// MaxStack to 99 when left out.
+func ParseItems(data []byte) (*ItemRegistry, error) {
+	var file struct {
+		Items []*ItemDef `json:"items"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	r := &ItemRegistry{byID: make(map[ItemID]*ItemDef)}
+	for i, def := range file.Items {
+		switch {
+		case def.ID == "":
+			return nil, fmt.Errorf("item %d has no id", i)
+		case r.byID[def.ID] != nil:
+			return nil, fmt.Errorf("item %q is defined twice", def.ID)
+		case def.Sprite == "" || def.Icon == "":
+			return nil, fmt.Errorf("item %q needs both a sprite and an icon", def.ID)
+		case def.Scale < 0 || def.MaxStack < 0 || def.Start < 0:
+			return nil, fmt.Errorf("item %q has a negative scale, stack size or start count", def.ID)
+		case def.SpriteFrame < 0 || def.IconFrame < 0:
+			return nil, fmt.Errorf("item %q has a negative frame", def.ID)
+		}
+		if def.Name == "" {
+			def.Name = string(def.ID)
+		}
+		if def.Scale == 0 {
+			def.Scale = 1
+		}
+		if def.MaxStack == 0 {
+			def.MaxStack = 99
+		}
+
+		r.defs = append(r.defs, def)
+		r.byID[def.ID] = def
+	}
+	return r, nil
+}
+
+// Get returns the definition of an item.
+func (r *ItemRegistry) Get(id ItemID) (*ItemDef, bool) {
+	def, ok := r.byID[id]
+	return def, ok
+}
+
+// All returns every definition in file order.
+func (r *ItemRegistry) All() []*ItemDef {
+	return slices.Clone(r.defs)
+}
+
+// IDs returns every item ID in file order.
+func (r *ItemRegistry) IDs() []string {
+	ids := make([]string, len(r.defs))
+	for i, def := range r.defs {
+		ids[i] = string(def.ID)
+	}
+	return ids
+}
+
+// Parse looks up an item by ID, for commands and config files.
+func (r *ItemRegistry) Parse(id string) (*ItemDef, error) {
+	def, ok := r.byID[ItemID(id)]
+	if !ok {
+		return nil, fmt.Errorf("unknown item %q", id)
+	}
+	return def, nil
+}
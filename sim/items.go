@@ -0,0 +1,208 @@
+package sim
+
+import "fmt"
+
+const (
+	// InteractionRadius is how close the player has to be to a world item
+	// to pick it up or plant it.
+	InteractionRadius = 150
+
+	// MergeRadius is how close an item has to land to another of its kind
+	// to join its stack.
+	MergeRadius = 40
+)
+
+// WorldItem is a stack of items lying in the world.
+type WorldItem struct {
+	Item     ItemID `json:"item"`
+	Count    int    `json:"count"`
+	Position Vec2   `json:"position"` // Where it is, or is over, on the ground
+
+	Motion      *ItemMotion `json:"motion,omitempty"`      // Nil once it comes to rest
+	PickupDelay float32     `json:"pickupDelay,omitempty"` // Seconds until it can be picked up
+
+	PrevPosition Vec2    `json:"-"` // Position at the start of the last step, for interpolation
+	PrevHeight   float32 `json:"-"`
+
+	key int // Identifies it in the world's spatial index
+}
+
+// clone returns a copy of the item that shares nothing with it.
+func (item WorldItem) clone() WorldItem {
+	if item.Motion != nil {
+		m := *item.Motion
+		item.Motion = &m
+	}
+	return item
+}
+
+// restored returns a copy of an item read back from a save, with nothing
+// left to interpolate from.
+func (item WorldItem) restored() WorldItem {
+	item = item.clone()
+	item.PrevPosition = item.Position
+	if item.Motion != nil {
+		item.PrevHeight = item.Motion.Height
+	}
+	return item
+}
+
+// GiveItem adds count of an item to the inventory. A negative count takes
+// items away, down to zero. Items that don't fit are lost and reported with
+// ErrInventoryFull.
+func (w *World) GiveItem(id ItemID, count int) error {
+	if _, ok := w.Config.Items.Get(id); !ok {
+		return fmt.Errorf("can't give unknown item %q", id)
+	}
+	if count < 0 {
+		w.Inventory.RemoveItem(id, -count)
+		inventoryLog.Debug("taken items", "item", id, "count", -count)
+		return nil
+	}
+	left, err := w.Inventory.AddItem(id, count)
+	inventoryLog.Debug("given items", "item", id, "count", count-left)
+	if err != nil {
+		return fmt.Errorf("gave %d of %d %s: %w", count-left, count, id, err)
+	}
+	return nil
+}
+
+// dropPosition returns where in front of the player a dropped item lands.
+func (w *World) dropPosition() Vec2 {
+	p := &w.Player
+	playerCenter := p.Center()
+
+	// Drop offset based on the direction the player is facing
+	var offsetX, offsetY float32
+
+	switch p.Dir {
+	case 0: // Down
+		offsetX = 0
+		offsetY = p.Dest.Height/2 + 30 // Drop in front of player
+	case 1: // Up
+		offsetX = 0
+		offsetY = -p.Dest.Height/2 - 30 // Drop above player
+	case 2: // Left
+		offsetX = -p.Dest.Width/2 - 30 // Drop to left of player
+		offsetY = 0
+	case 3: // Right
+		offsetX = p.Dest.Width/2 + 30 // Drop to right of player
+		offsetY = 0
+	}
+
+	return Vec2{
+		X: playerCenter.X + offsetX,
+		Y: playerCenter.Y + offsetY,
+	}
+}
+
+// dropSelected drops one item from the selected slot in front of the
+// player.
+func (w *World) dropSelected() {
+	dropped := w.Inventory.RemoveAt(w.Inventory.Selected, 1)
+	if dropped.Empty() {
+		inventoryLog.Debug("nothing to drop", "slot", w.Inventory.Selected+1)
+		return
+	}
+
+	pos := w.dropPosition()
+	w.tossItem(WorldItem{Item: dropped.Item, Count: dropped.Count}, w.Player.Center(), pos)
+
+	inventoryLog.Debug("dropped item", "item", dropped.Item, "target", pos, "dir", w.Player.Dir, "left", w.Inventory.Count(dropped.Item))
+}
+
+// SpawnItem puts count of an item down at pos, merging with nearby stacks
+// like a dropped item would.
+func (w *World) SpawnItem(id ItemID, count int, pos Vec2) {
+	def, ok := w.Config.Items.Get(id)
+	if !ok {
+		return
+	}
+	for count > 0 {
+		n := min(count, def.MaxStack)
+		w.landItem(WorldItem{Item: id, Count: n, Position: pos})
+		count -= n
+	}
+}
+
+// landItem puts an item down in the world. It joins the nearest stack of
+// the same item within MergeRadius that has room, spilling whatever doesn't
+// fit into a stack of its own.
+func (w *World) landItem(item WorldItem) {
+	if def, ok := w.Config.Items.Get(item.Item); ok {
+		for item.Count > 0 {
+			i := w.nearestItem(item.Position, MergeRadius, func(other *WorldItem) bool {
+				return other.Item == item.Item && other.Count < def.MaxStack && other.Motion == nil
+			})
+			if i < 0 {
+				break
+			}
+			stack := &w.WorldItems[i]
+			n := min(item.Count, def.MaxStack-stack.Count)
+			stack.Count += n
+			item.Count -= n
+			w.crystalChanged(stack, stack.Position)
+			inventoryLog.Debug("merged items", "item", item.Item, "count", n, "pos", stack.Position)
+		}
+	}
+	if item.Count > 0 {
+		item.PrevPosition = item.Position
+		w.addWorldItem(item)
+	}
+}
+
+// nearestItem returns the index of the world item closest to pos within
+// radius that match accepts, or -1.
+func (w *World) nearestItem(pos Vec2, radius float32, match func(*WorldItem) bool) int {
+	nearest, ok := w.itemIndex().Nearest(pos, radius, func(key int) bool { return match(&w.WorldItems[w.itemAt(key)]) })
+	if !ok {
+		return -1
+	}
+	return w.itemAt(nearest)
+}
+
+// takeWorldItem takes up to count items from world item i, removing it from
+// the world once it's empty.
+func (w *World) takeWorldItem(i, count int) WorldItem {
+	item := &w.WorldItems[i]
+	taken := *item
+	taken.Count = min(count, item.Count)
+	item.Count -= taken.Count
+	if item.Count <= 0 {
+		w.removeWorldItem(i)
+	} else {
+		w.crystalChanged(item, item.Position)
+	}
+	return taken
+}
+
+// takeNearbyPlantable takes one plantable item within reach of the player.
+func (w *World) takeNearbyPlantable() (WorldItem, bool) {
+	i := w.nearestItem(w.Player.Center(), InteractionRadius, func(item *WorldItem) bool {
+		def, ok := w.Config.Items.Get(item.Item)
+		return ok && def.HasTag(TagPlantable) && item.Motion == nil
+	})
+	if i < 0 {
+		return WorldItem{}, false
+	}
+	return w.takeWorldItem(i, 1), true
+}
+
+// interact picks up as much as fits of the item nearest the player.
+func (w *World) interact() {
+	playerCenter := w.Player.Center()
+	i := w.nearestItem(playerCenter, InteractionRadius, func(item *WorldItem) bool { return item.Motion == nil })
+	if i < 0 {
+		inventoryLog.Debug("nothing in range to pick up", "player", playerCenter)
+		return
+	}
+
+	item := w.WorldItems[i]
+	left, err := w.Inventory.AddItem(item.Item, item.Count)
+	if left == item.Count {
+		inventoryLog.Debug("can't pick up", "item", item.Item, "err", err)
+		return
+	}
+	w.takeWorldItem(i, item.Count-left)
+	inventoryLog.Debug("picked up item", "item", item.Item, "count", item.Count-left, "pos", item.Position)
+}
@@ -0,0 +1,11 @@
+package sim
+
+import "main/logging"
+
+var (
+	inputLog     = logging.For("input")
+	inventoryLog = logging.For("inventory")
+	treesLog     = logging.For("trees")
+	particlesLog = logging.For("particles")
+	worldLog     = logging.For("world")
+)
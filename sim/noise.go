@@ -0,0 +1,62 @@
+package sim
+
+import "math"
+
+// Noise fields for world generation. Chunks are generated in whatever order
+// the player walks into them, so nothing here draws from a random stream:
+// every value is a pure function of a seed and a position, and a chunk comes
+// out the same whenever it's made.
+
+// hash2 mixes a seed and a lattice point into 64 well-scrambled bits.
+func hash2(seed int64, x, y int) uint64 {
+	z := uint64(seed) ^ uint64(int64(x))*0x9e3779b97f4a7c15 ^ uint64(int64(y))*0xc2b2ae3d27d4eb4f
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// unitHash returns a number in [0, 1) that only depends on the seed and the
+// point.
+func unitHash(seed int64, x, y int) float64 {
+	return float64(hash2(seed, x, y)>>11) / (1 << 53)
+}
+
+// valueNoise is smooth noise in [-1, 1]: random values on the integer
+// lattice, blended between with a smoothstep so there are no creases.
+func valueNoise(seed int64, x, y float64) float64 {
+	x0, y0 := math.Floor(x), math.Floor(y)
+	ix, iy := int(x0), int(y0)
+	fx, fy := smoothstep(x-x0), smoothstep(y-y0)
+
+	corner := func(dx, dy int) float64 {
+		return unitHash(seed, ix+dx, iy+dy)*2 - 1
+	}
+	top := lerp(corner(0, 0), corner(1, 0), fx)
+	bottom := lerp(corner(0, 1), corner(1, 1), fx)
+	return lerp(top, bottom, fy)
+}
+
+// fractalNoise layers octaves of valueNoise, each at twice the frequency and
+// half the strength of the one before, for shapes with both broad sweeps and
+// ragged edges. scale is the size of the broadest features. The result is in
+// [-1, 1].
+func fractalNoise(seed int64, x, y, scale float64, octaves int) float64 {
+	var sum, total float64
+	amplitude := 1.0
+	x, y = x/scale, y/scale
+	for i := range octaves {
+		sum += valueNoise(seed+int64(i), x, y) * amplitude
+		total += amplitude
+		x, y = x*2, y*2
+		amplitude /= 2
+	}
+	return sum / total
+}
+
+func smoothstep(t float64) float64 {
+	return t * t * (3 - 2*t)
+}
+
+func lerp(a, b, t float64) float64 {
+	return a + (b-a)*t
+}
@@ -0,0 +1,86 @@
+package sim
+
+import "math"
+
+const (
+	// ParticleGravity pulls particles down, in pixels per second squared.
+	ParticleGravity float32 = 360
+
+	// ParticleLifetime is how long a particle lives, in seconds.
+	ParticleLifetime float32 = 5.0 / 6
+)
+
+type Particle struct {
+	Position     Vec2
+	PrevPosition Vec2    // Position at the start of the last step, for interpolation
+	Velocity     Vec2    // Pixels per second
+	Gravity      float32 // Downward pull, in pixels per second squared
+	Color        Color
+	Size         float32
+	Life         float32 // Fraction of the lifetime left, from 1 down to 0
+	MaxLife      float32
+}
+
+// CreateSplashEffect bursts a ring of water particles out from (x, y).
+func (w *World) CreateSplashEffect(x, y float32) {
+	numParticles := 20                                                     // Number of particles in the splash
+	w.burst(Vec2{x, y}, numParticles, Color{100, 200, 255, 255}, 120, 300) // Light blue
+	particlesLog.Debug("splash", "pos", Vec2{x, y}, "particles", numParticles, "live", len(w.Particles))
+}
+
+// burst throws n particles of one color out from pos in random directions,
+// at speeds between minSpeed and maxSpeed pixels per second.
+func (w *World) burst(pos Vec2, n int, c Color, minSpeed, maxSpeed float32) {
+	rng := w.RNG(StreamParticles)
+	for i := 0; i < n; i++ {
+		// Random angle for particle direction
+		angle := float32(rng.Float64() * math.Pi * 2)
+		speed := minSpeed + float32(rng.Float64())*(maxSpeed-minSpeed)
+
+		particle := Particle{
+			Position:     pos,
+			PrevPosition: pos,
+			Gravity:      ParticleGravity,
+			Velocity: Vec2{
+				X: float32(math.Cos(float64(angle))) * speed,
+				Y: float32(math.Sin(float64(angle))) * speed,
+			},
+			Color:   c,
+			Size:    float32(2 + rng.Float64()*3), // Random size between 2 and 5
+			Life:    1.0,                          // Full life
+			MaxLife: 1.0,                          // Maximum life
+		}
+		w.Particles = append(w.Particles, particle)
+	}
+}
+
+func (w *World) updateParticles(dt float32) {
+	// Live particles are copied forward over dead ones, and the slice is cut
+	// once at the end.
+	live := 0
+	for i := range w.Particles {
+		p := &w.Particles[i]
+		p.PrevPosition = p.Position
+
+		// Update position
+		p.Position.X += p.Velocity.X * dt
+		p.Position.Y += p.Velocity.Y * dt
+
+		// Apply gravity
+		p.Velocity.Y += p.Gravity * dt
+
+		// Reduce life
+		p.Life -= p.MaxLife / ParticleLifetime * dt
+
+		if p.Life > 0 {
+			w.Particles[live] = *p
+			live++
+		}
+	}
+	w.Particles = w.Particles[:live]
+}
+
+// ClearParticles removes every live particle.
+func (w *World) ClearParticles() {
+	w.Particles = w.Particles[:0]
+}
@@ -0,0 +1,96 @@
+package sim
+
+const (
+	// PlayerSpeed is how fast the player walks, in pixels per second.
+	PlayerSpeed float32 = 360
+
+	// WalkFrameTime is how long each frame of the walk cycle is shown, in
+	// seconds.
+	WalkFrameTime float32 = 8.0 / 60
+)
+
+// Player is the character the user controls.
+type Player struct {
+	Src       Rect // Current frame in the sprite sheet
+	Dest      Rect
+	PrevDest  Rect // Dest at the start of the last step, for interpolation
+	Moving    bool
+	Dir       int  // 0 down, 1 up, 2 left, 3 right
+	Move      Vec2 // Requested movement this step, each axis from -1 to 1
+	Frame     int
+	FrameTime float32 // Time spent on the current walk frame
+	SwingTime float32 // Seconds left in the current tool swing
+}
+
+// Center returns the middle of the player's destination rectangle.
+func (p *Player) Center() Vec2 {
+	return Vec2{
+		X: p.Dest.X + p.Dest.Width/2,
+		Y: p.Dest.Y + p.Dest.Height/2,
+	}
+}
+
+// Teleport moves the player so they are centered on pos.
+func (p *Player) Teleport(pos Vec2) {
+	p.Dest.X = pos.X - p.Dest.Width/2
+	p.Dest.Y = pos.Y - p.Dest.Height/2
+	p.PrevDest = p.Dest
+}
+
+// InterpolatedDest blends the previous and current destination rectangles.
+// alpha is how far the renderer is between the last step and the next one.
+func (p *Player) InterpolatedDest(alpha float32) Rect {
+	pos := Lerp(Vec2{p.PrevDest.X, p.PrevDest.Y}, Vec2{p.Dest.X, p.Dest.Y}, alpha)
+	return Rect{pos.X, pos.Y, p.Dest.Width, p.Dest.Height}
+}
+
+func (p *Player) movePressed(in Inputs) {
+	p.Move = Vec2{clampUnit(in.MoveX), clampUnit(in.MoveY)}
+	p.Moving = p.Move != Vec2{}
+
+	// Horizontal movement wins the facing direction, as it always has when
+	// walking diagonally.
+	switch {
+	case p.Move.X > 0:
+		p.Dir = 3
+	case p.Move.X < 0:
+		p.Dir = 2
+	case p.Move.Y > 0:
+		p.Dir = 0
+	case p.Move.Y < 0:
+		p.Dir = 1
+	}
+}
+
+func clampUnit(v float32) float32 {
+	return max(-1, min(v, 1))
+}
+
+func (w *World) updatePlayer(dt float32) {
+	p := &w.Player
+	p.PrevDest = p.Dest
+	p.SwingTime = max(p.SwingTime-dt, 0)
+
+	if p.Moving {
+		step := PlayerSpeed * dt
+		feet := p.Footprint()
+		moved := w.moveBox(feet, Vec2{p.Move.X * step, p.Move.Y * step})
+		p.Dest.X += moved.X - feet.X
+		p.Dest.Y += moved.Y - feet.Y
+
+		p.FrameTime += dt
+		for p.FrameTime >= WalkFrameTime {
+			p.FrameTime -= WalkFrameTime
+			p.Frame++
+		}
+	}
+	if p.Frame > 3 {
+		p.Frame = 0
+	}
+
+	p.Src.X = p.Src.Width * float32(p.Frame)
+	p.Src.Y = p.Src.Height * float32(p.Dir)
+
+	p.Moving = false
+	p.Move = Vec2{}
+}
@@ -0,0 +1,172 @@
+package sim
+
+import (
+	"math"
+	"testing"
+)
+
+func TestStepMovement(t *testing.T) {
+	type tree struct {
+		pos   Vec2
+		stage TreeStage
+		stump bool
+	}
+	tests := []struct {
+		name  string
+		start Vec2 // The player's center
+		trees []tree
+		in    Inputs
+		ticks int
+		want  Vec2
+	}{
+		{
+			name:  "open ground",
+			start: Vec2{1000, 1000},
+			in:    Inputs{MoveX: 1},
+			ticks: 60,
+			want:  Vec2{1360, 1000},
+		},
+		{
+			name:  "diagonal",
+			start: Vec2{1000, 1000},
+			in:    Inputs{MoveX: -1, MoveY: 1},
+			ticks: 60,
+			want:  Vec2{640, 1360},
+		},
+		{
+			name:  "half a stick",
+			start: Vec2{1000, 1000},
+			in:    Inputs{MoveY: -0.5},
+			ticks: 60,
+			want:  Vec2{1000, 820},
+		},
+		{
+			name:  "clamped",
+			start: Vec2{1000, 1000},
+			in:    Inputs{MoveX: 3},
+			ticks: 60,
+			want:  Vec2{1360, 1000},
+		},
+		{
+			name:  "stopped by a tree",
+			start: Vec2{1000, 1000},
+			trees: []tree{{Vec2{1100, 1020}, StageMature, false}},
+			in:    Inputs{MoveX: 1},
+			ticks: 60,
+			want:  Vec2{1070, 1000},
+		},
+		{
+			name:  "stopped by a stump",
+			start: Vec2{1000, 1000},
+			trees: []tree{{Vec2{1100, 1020}, StageMature, true}},
+			in:    Inputs{MoveX: 1},
+			ticks: 60,
+			want:  Vec2{1070, 1000},
+		},
+		{
+			name:  "through a seedling",
+			start: Vec2{1000, 1000},
+			trees: []tree{{Vec2{1100, 1020}, StageSeedling, false}},
+			in:    Inputs{MoveX: 1},
+			ticks: 60,
+			want:  Vec2{1360, 1000},
+		},
+		{
+			name:  "stopped by the nest",
+			start: Vec2{400, 500},
+			in:    Inputs{MoveY: -1},
+			ticks: 60,
+			want:  Vec2{400, 392},
+		},
+		{
+			name:  "behind the nest",
+			start: Vec2{300, 392},
+			in:    Inputs{MoveX: 1},
+			ticks: 60,
+			want:  Vec2{660, 392},
+		},
+		{
+			name:  "slides along the nest",
+			start: Vec2{400, 392},
+			in:    Inputs{MoveX: 1, MoveY: -1},
+			ticks: 60,
+			want:  Vec2{760, 104},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWorld(Config{ViewWidth: 1920})
+			w.Player.Teleport(tt.start)
+			for _, tree := range tt.trees {
+				w.SpawnTree(tree.pos, tree.stage)
+				if tree.stump {
+					// A fresh stump, a long way from sprouting
+					stump := &w.Trees[len(w.Trees)-1]
+					stump.Stump, stump.Age = true, 0
+				}
+			}
+			for range tt.ticks {
+				w.Step(tt.in, 1.0/60)
+			}
+			got := w.Player.Center()
+			if math.Abs(float64(got.X-tt.want.X)) > 0.01 || math.Abs(float64(got.Y-tt.want.Y)) > 0.01 {
+				t.Errorf("player at %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStepWalkCycle(t *testing.T) {
+	w := NewWorld(Config{ViewWidth: 1920})
+	var frames []int
+	for range 40 {
+		w.Step(Inputs{MoveX: 1}, 1.0/60)
+		frames = append(frames, w.Player.Frame)
+	}
+	// A new frame every WalkFrameTime, 8 steps, wrapping after 4.
+	for i, frame := range frames {
+		if want := (i + 1) / 8 % 4; frame != want {
+			t.Fatalf("frame after step %d is %d, want %d (all: %v)", i+1, frame, want, frames)
+		}
+	}
+	if w.Player.Src.X != w.Player.Src.Width*float32(w.Player.Frame) || w.Player.Src.Y != w.Player.Src.Height*3 {
+		t.Errorf("sprite source %v doesn't match frame %d facing right", w.Player.Src, w.Player.Frame)
+	}
+}
+
+func TestStepInterpolation(t *testing.T) {
+	w := NewWorld(Config{ViewWidth: 1920})
+	w.Step(Inputs{MoveX: 1}, 1.0/60)
+	tests := []struct {
+		alpha float32
+		wantX float32
+	}{
+		{0, 200},
+		{0.5, 203},
+		{1, 206},
+	}
+	for _, tt := range tests {
+		if got := w.Player.InterpolatedDest(tt.alpha); got.X != tt.wantX || got.Y != 200 {
+			t.Errorf("InterpolatedDest(%v) at %v,%v, want %v,200", tt.alpha, got.X, got.Y, tt.wantX)
+		}
+	}
+}
+
+func TestInputsLatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		prev, next Inputs
+		want       Inputs
+	}{
+		{"movement follows the newest", Inputs{MoveY: -1}, Inputs{MoveX: -0.5}, Inputs{MoveX: -0.5}},
+		{"actions stay set", Inputs{Plant: true, MoveY: -1}, Inputs{}, Inputs{Plant: true}},
+		{"actions add up", Inputs{Splash: true}, Inputs{Plant: true}, Inputs{Splash: true, Plant: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.prev.Latch(tt.next); got != tt.want {
+				t.Errorf("Latch = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
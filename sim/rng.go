@@ -0,0 +1,61 @@
+package sim
+
+import (
+	"hash/fnv"
+	"math/rand"
+)
+
+// Stream names an independent random number stream. Each subsystem draws
+// only from its own stream, so cosmetic effects like particles never shift
+// the numbers gameplay sees, and adding draws to one system doesn't change
+// what another does for the same seed.
+type Stream int
+
+const (
+	StreamGameplay  Stream = iota // Rules that change the saved world
+	StreamWorldGen                // Terrain and initial placement
+	StreamAI                      // Creature decisions
+	StreamParticles               // Cosmetic effects only
+
+	streamCount
+)
+
+var streamNames = [streamCount]string{
+	StreamGameplay:  "gameplay",
+	StreamWorldGen:  "worldgen",
+	StreamAI:        "ai",
+	StreamParticles: "particles",
+}
+
+func (s Stream) String() string {
+	return streamNames[s]
+}
+
+// DeriveSeed turns the world seed into the seed for one named stream. It
+// mixes the two with SplitMix64 so nearby world seeds still give unrelated
+// streams.
+func DeriveSeed(seed int64, name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+
+	z := uint64(seed) ^ h.Sum64()
+	z += 0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return int64(z ^ (z >> 31))
+}
+
+type rngs [streamCount]*rand.Rand
+
+func newRNGs(seed int64) rngs {
+	var r rngs
+	for s := range r {
+		r[s] = rand.New(rand.NewSource(DeriveSeed(seed, streamNames[s])))
+	}
+	return r
+}
+
+// RNG returns the world's random number generator for one stream.
+func (w *World) RNG(s Stream) *rand.Rand {
+	return w.rngs[s]
+}
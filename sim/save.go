@@ -0,0 +1,346 @@
+package sim
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// SaveVersion is the schema version written by EncodeSave. Bump it whenever
+// SaveData changes shape, and add a migration from the previous version.
+const SaveVersion = 9
+
+// SaveData is everything about a world that outlives a play session.
+// Particles and clouds are cosmetic and start fresh on load.
+type SaveData struct {
+	Version int `json:"version"`
+
+	Seed  int64   `json:"seed"`
+	Time  float32 `json:"time"`
+	Ticks int     `json:"ticks"`
+
+	Player SavedPlayer `json:"player"`
+
+	Inventory  SavedInventory `json:"inventory"`
+	WorldItems []WorldItem    `json:"worldItems"`
+
+	Trees []SavedTree `json:"trees"`
+
+	// Chunks are the loaded chunks of a generated world and the unloaded
+	// ones that were changed; the rest are made again from the seed. On a
+	// hand-made map they're the chunks whose tiles were edited.
+	Chunks []SavedChunk `json:"chunks,omitempty"`
+}
+
+type SavedPlayer struct {
+	X   float32 `json:"x"`
+	Y   float32 `json:"y"`
+	Dir int     `json:"dir"`
+}
+
+type SavedInventory struct {
+	Slots    []InventorySlot `json:"slots"`
+	Selected int             `json:"selected"`
+}
+
+type SavedTree struct {
+	Position Vec2      `json:"position"`
+	Seed     ItemID    `json:"seed"`
+	Stage    TreeStage `json:"stage"`
+	Age      float32   `json:"age"`
+	ConeTime float32   `json:"coneTime"`
+	Stump    bool      `json:"stump,omitempty"`
+	Hits     int       `json:"hits,omitempty"`
+}
+
+// migrations[v] upgrades a decoded save from version v to v+1. They work on
+// the generic JSON form so old files never need the old Go types. Numbers in
+// that form are json.Numbers, so 64-bit values like seeds survive intact.
+var migrations = map[int]func(save map[string]any) error{
+	1: func(save map[string]any) error {
+		// Version 1 didn't store the seed; those worlds continue on seed 0.
+		save["seed"] = json.Number("0")
+		return nil
+	},
+	2: func(save map[string]any) error {
+		// Version 2 had a counter and a dropped list per item type.
+		save["items"] = map[string]any{
+			"pinecone": save["pineConeCount"],
+			"crystal":  save["crystalStoneCount"],
+		}
+		var dropped []any
+		for _, old := range []struct{ key, id string }{
+			{"droppedPineCones", "pinecone"},
+			{"droppedCrystalStones", "crystal"},
+		} {
+			list, _ := save[old.key].([]any)
+			for _, pos := range list {
+				dropped = append(dropped, map[string]any{"item": old.id, "position": pos})
+			}
+		}
+		save["dropped"] = dropped
+		for _, key := range []string{"pineConeCount", "crystalStoneCount", "droppedPineCones", "droppedCrystalStones"} {
+			delete(save, key)
+		}
+		return nil
+	},
+	3: func(save map[string]any) error {
+		// Version 3 kept a count per item instead of slots. Pine cones and
+		// crystals go back where the old bag showed them, anything else after
+		// them in ID order.
+		counts, _ := save["items"].(map[string]any)
+		ids := []string{"pinecone", "crystal"}
+		for _, id := range slices.Sorted(maps.Keys(counts)) {
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+		var slots []any
+		for _, id := range ids {
+			if count, ok := counts[id]; ok {
+				slots = append(slots, map[string]any{"item": id, "count": count})
+			}
+		}
+		save["inventory"] = map[string]any{"slots": slots, "selected": json.Number("0")}
+		delete(save, "items")
+		return nil
+	},
+	4: func(save map[string]any) error {
+		// Version 4 dropped items one at a time, so every one was a stack
+		// of one.
+		dropped, _ := save["dropped"].([]any)
+		for _, item := range dropped {
+			if item, ok := item.(map[string]any); ok {
+				item["count"] = json.Number("1")
+			}
+		}
+		save["worldItems"] = dropped
+		delete(save, "dropped")
+		return nil
+	},
+	5: func(save map[string]any) error {
+		// Version 6 added tossing. Version 5 items were always at rest with
+		// no pickup delay, which is what the missing fields decode to.
+		return nil
+	},
+	6: func(save map[string]any) error {
+		// Version 6 trees stepped through frames a second apart and stopped.
+		// The frame becomes the stage, starting from its beginning.
+		trees, _ := save["trees"].([]any)
+		for i, tree := range trees {
+			tree, ok := tree.(map[string]any)
+			if !ok {
+				continue
+			}
+			n, ok := tree["frame"].(json.Number)
+			if !ok {
+				return fmt.Errorf("tree %d: bad frame", i)
+			}
+			frame, err := n.Int64()
+			if err != nil {
+				return fmt.Errorf("tree %d: bad frame", i)
+			}
+			stage := max(StageSeedling, min(TreeStage(frame), StageMature))
+			tree["seed"] = "pinecone"
+			tree["stage"] = json.Number(fmt.Sprint(int(stage)))
+			tree["age"] = json.Number(fmt.Sprint(stageStart(stage)))
+			tree["coneTime"] = json.Number("0")
+			for _, key := range []string{"frame", "growing", "growthTime"} {
+				delete(tree, key)
+			}
+		}
+		return nil
+	},
+	7: func(save map[string]any) error {
+		// Version 7 had no axe. Hand one over, in the first empty slot if
+		// there is one, so old worlds can chop trees too.
+		inv, _ := save["inventory"].(map[string]any)
+		if inv == nil {
+			return nil
+		}
+		slots, _ := inv["slots"].([]any)
+		axe := map[string]any{"item": "axe", "count": json.Number("1")}
+		for i, slot := range slots {
+			if slot, ok := slot.(map[string]any); ok && slot["item"] == nil {
+				slots[i] = axe
+				return nil
+			}
+		}
+		if len(slots) < InventorySize {
+			inv["slots"] = append(slots, axe)
+		}
+		return nil
+	},
+	8: func(save map[string]any) error {
+		// Version 8 worlds were all hand-made maps, with no chunks to keep.
+		// Their trees and items load as they were; on a generated map the
+		// chunks around the player are made fresh.
+		return nil
+	},
+}
+
+// Save captures the world's persistent state.
+func (w *World) Save() SaveData {
+	d := SaveData{
+		Version: SaveVersion,
+		Seed:    w.Config.Seed,
+		Time:    w.Time,
+		Ticks:   w.Ticks,
+		Player: SavedPlayer{
+			X:   w.Player.Dest.X,
+			Y:   w.Player.Dest.Y,
+			Dir: w.Player.Dir,
+		},
+		Inventory: SavedInventory{
+			Slots:    append([]InventorySlot(nil), w.Inventory.Slots...),
+			Selected: w.Inventory.Selected,
+		},
+		WorldItems: make([]WorldItem, 0, len(w.WorldItems)),
+		Chunks:     w.saveChunks(),
+	}
+	for _, item := range w.WorldItems {
+		d.WorldItems = append(d.WorldItems, item.clone())
+	}
+	for _, tree := range w.Trees {
+		d.Trees = append(d.Trees, tree.saved())
+	}
+	return d
+}
+
+func (t *Tree) saved() SavedTree {
+	return SavedTree{
+		Position: t.Position,
+		Seed:     t.Seed,
+		Stage:    t.Stage,
+		Age:      t.Age,
+		ConeTime: t.ConeTime,
+		Stump:    t.Stump,
+		Hits:     t.Hits,
+	}
+}
+
+func (t SavedTree) tree() Tree {
+	return Tree{
+		Position: t.Position,
+		Seed:     t.Seed,
+		Stage:    max(StageSeedling, min(t.Stage, StageMature)),
+		Age:      t.Age,
+		ConeTime: t.ConeTime,
+		Stump:    t.Stump,
+		Hits:     t.Hits,
+	}
+}
+
+// Load replaces the world's persistent state with d. The random streams are
+// reseeded from the save's seed. Items the registry doesn't know are left
+// out, so a save from a build with more items still loads, and stacks that
+// no longer fit their slots spill into free ones.
+func (w *World) Load(d SaveData) {
+	w.Config.Seed = d.Seed
+	w.rngs = newRNGs(d.Seed)
+
+	w.Time = d.Time
+	w.Ticks = d.Ticks
+
+	w.Player.Dest.X, w.Player.Dest.Y = d.Player.X, d.Player.Y
+	w.Player.PrevDest = w.Player.Dest
+	w.Player.Dir = d.Player.Dir
+	w.Player.Frame = 0
+	w.Player.FrameTime = 0
+
+	w.Inventory = NewInventory(InventorySize, w.Config.Items)
+	var overflow []InventorySlot
+	for i, slot := range d.Inventory.Slots {
+		if slot.Empty() {
+			continue
+		}
+		def, ok := w.Config.Items.Get(slot.Item)
+		if !ok {
+			inventoryLog.Warn("dropping unknown item from save", "item", slot.Item, "count", slot.Count)
+			continue
+		}
+		if i < InventorySize && slot.Count <= def.MaxStack {
+			w.Inventory.Slots[i] = slot
+		} else {
+			overflow = append(overflow, slot)
+		}
+	}
+	// Overflow goes in last, so the stacks the save placed keep their slots.
+	for _, slot := range overflow {
+		if left, err := w.Inventory.AddItem(slot.Item, slot.Count); err != nil {
+			inventoryLog.Warn("dropping items that don't fit", "item", slot.Item, "count", left)
+		}
+	}
+	w.Inventory.Select(d.Inventory.Selected)
+
+	w.clearWorldItems()
+	w.trees.stale = true
+	for _, item := range d.WorldItems {
+		if _, ok := w.Config.Items.Get(item.Item); !ok || item.Count <= 0 {
+			inventoryLog.Warn("dropping unknown or empty item from save", "item", item.Item, "count", item.Count, "pos", item.Position)
+			continue
+		}
+		w.addWorldItem(item.restored())
+	}
+
+	w.Trees = make([]Tree, 0, len(d.Trees))
+	for _, tree := range d.Trees {
+		w.addTree(tree.tree())
+	}
+	w.loadChunks(d.Chunks)
+
+	w.Particles = w.Particles[:0]
+}
+
+// EncodeSave serializes the world's persistent state as JSON.
+func EncodeSave(w *World) ([]byte, error) {
+	return json.MarshalIndent(w.Save(), "", "  ")
+}
+
+// DecodeSave parses a save file written by any version of the game,
+// migrating it to the current schema first.
+func DecodeSave(data []byte) (SaveData, error) {
+	var raw map[string]any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&raw); err != nil {
+		return SaveData{}, fmt.Errorf("parsing save: %w", err)
+	}
+
+	version, ok := raw["version"].(json.Number)
+	if !ok {
+		return SaveData{}, fmt.Errorf("save has no version")
+	}
+	v64, err := version.Int64()
+	if err != nil {
+		return SaveData{}, fmt.Errorf("save version %s is not a whole number", version)
+	}
+
+	v := int(v64)
+	if v > SaveVersion {
+		return SaveData{}, fmt.Errorf("save is version %d, newer than this game's %d", v, SaveVersion)
+	}
+	for ; v < SaveVersion; v++ {
+		migrate, ok := migrations[v]
+		if !ok {
+			return SaveData{}, fmt.Errorf("no migration from save version %d", v)
+		}
+		if err := migrate(raw); err != nil {
+			return SaveData{}, fmt.Errorf("migrating save from version %d: %w", v, err)
+		}
+		raw["version"] = json.Number(fmt.Sprint(v + 1))
+	}
+
+	// Round-trip through JSON to get from the generic form to SaveData.
+	migrated, err := json.Marshal(raw)
+	if err != nil {
+		return SaveData{}, err
+	}
+	var d SaveData
+	if err := json.Unmarshal(migrated, &d); err != nil {
+		return SaveData{}, fmt.Errorf("parsing save: %w", err)
+	}
+	return d, nil
+}
@@ -0,0 +1,276 @@
+package sim
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"main/res"
+	"main/tilemap"
+)
+
+// testItems has small stacks, so tests can fill slots without big numbers.
+func testItems(t *testing.T) *ItemRegistry {
+	t.Helper()
+	items, err := ParseItems([]byte(`{"items": [
+		{"id": "cone", "sprite": "cone", "icon": "cone", "maxStack": 5, "tags": ["plantable"]},
+		{"id": "gem", "sprite": "gem", "icon": "gem", "maxStack": 3},
+		{"id": "axe", "sprite": "axe", "icon": "axe", "maxStack": 1, "tags": ["axe"]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return items
+}
+
+// checkErr fails t unless err is nil when want is empty, or contains want.
+func checkErr(t *testing.T, err error, want string) {
+	t.Helper()
+	if want == "" && err != nil || want != "" && (err == nil || !strings.Contains(err.Error(), want)) {
+		t.Errorf("err = %v, want %q", err, want)
+	}
+}
+
+func TestSaveRoundTrip(t *testing.T) {
+	items := testItems(t)
+	w := NewWorld(Config{ViewWidth: 1920, Items: items})
+	for tick := range 120 {
+		in := Inputs{MoveX: float32(1 - tick/60), MoveY: float32(tick / 60), Drop: tick == 30 || tick == 90}
+		if tick == 60 {
+			in.Select = 2
+		}
+		w.Step(in, 1.0/60)
+	}
+	data, err := EncodeSave(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := DecodeSave(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := NewWorld(Config{ViewWidth: 1920, Items: items})
+	loaded.Load(d)
+	got, err := EncodeSave(loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("loaded world saves as\n%s\nwant\n%s", got, data)
+	}
+}
+
+func TestDecodeSaveMigrations(t *testing.T) {
+	// Every version from 1 on describes the same world: a player, a few
+	// items in the bag and on the ground, and a tree.
+	player := SavedPlayer{X: 10, Y: 20, Dir: 2}
+	tree := SavedTree{Position: Vec2{5, 6}, Seed: "pinecone", Stage: StageYoung, Age: stageStart(StageYoung)}
+	want := SaveData{
+		Version: SaveVersion,
+		Seed:    42,
+		Time:    5,
+		Ticks:   300,
+		Player:  player,
+		Inventory: SavedInventory{
+			Slots: []InventorySlot{{"pinecone", 3}, {"crystal", 1}, {"axe", 1}},
+		},
+		WorldItems: []WorldItem{
+			{Item: "pinecone", Count: 1, Position: Vec2{1, 2}},
+			{Item: "crystal", Count: 1, Position: Vec2{3, 4}},
+		},
+		Trees: []SavedTree{tree},
+	}
+	const common = `"time": 5, "ticks": 300, "player": {"x": 10, "y": 20, "dir": 2}`
+	const oldTree = `"trees": [{"position": {"x": 5, "y": 6}, "frame": 2, "growing": true, "growthTime": 0.5}]`
+	const newTree = `"trees": [{"position": {"x": 5, "y": 6}, "seed": "pinecone", "stage": 2, "age": 90, "coneTime": 0}]`
+	const slots = `"inventory": {"slots": [{"item": "pinecone", "count": 3}, {"item": "crystal", "count": 1}], "selected": 0}`
+	const worldItems = `"worldItems": [
+		{"item": "pinecone", "count": 1, "position": {"x": 1, "y": 2}},
+		{"item": "crystal", "count": 1, "position": {"x": 3, "y": 4}}]`
+
+	tests := []struct {
+		name string
+		save string
+		want func(d *SaveData) // Changes from want, if any
+	}{
+		{
+			name: "version 1",
+			save: `{"version": 1, ` + common + `, "pineConeCount": 3, "crystalStoneCount": 1,
+				"droppedPineCones": [{"x": 1, "y": 2}], "droppedCrystalStones": [{"x": 3, "y": 4}], ` + oldTree + `}`,
+			want: func(d *SaveData) { d.Seed = 0 },
+		},
+		{
+			name: "version 2",
+			save: `{"version": 2, "seed": 42, ` + common + `, "pineConeCount": 3, "crystalStoneCount": 1,
+				"droppedPineCones": [{"x": 1, "y": 2}], "droppedCrystalStones": [{"x": 3, "y": 4}], ` + oldTree + `}`,
+		},
+		{
+			name: "version 3",
+			save: `{"version": 3, "seed": 42, ` + common + `, "items": {"wood": 2, "crystal": 1, "pinecone": 3},
+				"dropped": [{"item": "pinecone", "position": {"x": 1, "y": 2}}, {"item": "crystal", "position": {"x": 3, "y": 4}}], ` + oldTree + `}`,
+			want: func(d *SaveData) {
+				d.Inventory.Slots = []InventorySlot{{"pinecone", 3}, {"crystal", 1}, {"wood", 2}, {"axe", 1}}
+			},
+		},
+		{
+			name: "version 4",
+			save: `{"version": 4, "seed": 42, ` + common + `, ` + slots + `,
+				"dropped": [{"item": "pinecone", "position": {"x": 1, "y": 2}}, {"item": "crystal", "position": {"x": 3, "y": 4}}], ` + oldTree + `}`,
+		},
+		{
+			name: "version 5",
+			save: `{"version": 5, "seed": 42, ` + common + `, ` + slots + `, ` + worldItems + `, ` + oldTree + `}`,
+		},
+		{
+			name: "version 6",
+			save: `{"version": 6, "seed": 42, ` + common + `, ` + slots + `, ` + worldItems + `, ` + oldTree + `}`,
+		},
+		{
+			name: "version 6 frame past mature",
+			save: `{"version": 6, "seed": 42, ` + common + `, ` + slots + `, ` + worldItems + `,
+				"trees": [{"position": {"x": 5, "y": 6}, "frame": 7}]}`,
+			want: func(d *SaveData) {
+				d.Trees = []SavedTree{{Position: Vec2{5, 6}, Seed: "pinecone", Stage: StageMature, Age: stageStart(StageMature)}}
+			},
+		},
+		{
+			name: "version 7",
+			save: `{"version": 7, "seed": 42, ` + common + `, ` + slots + `, ` + worldItems + `, ` + newTree + `}`,
+		},
+		{
+			name: "version 7 axe in an empty slot",
+			save: `{"version": 7, "seed": 42, ` + common + `, ` + worldItems + `, ` + newTree + `,
+				"inventory": {"slots": [{"item": "pinecone", "count": 3}, {}, {"item": "crystal", "count": 1}], "selected": 0}}`,
+			want: func(d *SaveData) {
+				d.Inventory.Slots = []InventorySlot{{"pinecone", 3}, {"axe", 1}, {"crystal", 1}}
+			},
+		},
+		{
+			name: "version 7 full bag",
+			save: `{"version": 7, "seed": 42, ` + common + `, ` + worldItems + `, ` + newTree + `,
+				"inventory": {"slots": [{"item": "pinecone", "count": 3}, {"item": "crystal", "count": 1}, {"item": "wood", "count": 1}, {"item": "wood", "count": 2}], "selected": 0}}`,
+			want: func(d *SaveData) {
+				d.Inventory.Slots = []InventorySlot{{"pinecone", 3}, {"crystal", 1}, {"wood", 1}, {"wood", 2}}
+			},
+		},
+		{
+			name: "version 8",
+			save: `{"version": 8, "seed": 42, ` + common + `, ` + worldItems + `, ` + newTree + `,
+				"inventory": {"slots": [{"item": "pinecone", "count": 3}, {"item": "crystal", "count": 1}, {"item": "axe", "count": 1}], "selected": 0}}`,
+		},
+		{
+			name: "version 9",
+			save: `{"version": 9, "seed": 42, ` + common + `, ` + worldItems + `, ` + newTree + `,
+				"inventory": {"slots": [{"item": "pinecone", "count": 3}, {"item": "crystal", "count": 1}, {"item": "axe", "count": 1}], "selected": 0}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeSave([]byte(tt.save))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := want
+			want.Inventory.Slots = slices.Clone(want.Inventory.Slots)
+			want.Trees = slices.Clone(want.Trees)
+			if tt.want != nil {
+				tt.want(&want)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("DecodeSave =\n%+v\nwant\n%+v", got, want)
+			}
+		})
+	}
+}
+
+func TestDecodeSaveBadVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		save    string
+		wantErr string
+	}{
+		{"missing", `{"seed": 1}`, "save has no version"},
+		{"fraction", `{"version": 1.5}`, "not a whole number"},
+		{"too old", `{"version": 0}`, "no migration from save version 0"},
+		{"too new", fmt.Sprintf(`{"version": %d}`, SaveVersion+1), fmt.Sprintf("newer than this game's %d", SaveVersion)},
+		{"not JSON", `version 1`, "parsing save"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeSave([]byte(tt.save))
+			checkErr(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestDecodeSaveBadTreeFrame(t *testing.T) {
+	tests := []struct {
+		name string
+		tree string
+	}{
+		{"missing", `{"position": {"x": 1, "y": 2}}`},
+		{"string", `{"position": {"x": 1, "y": 2}, "frame": "x"}`},
+		{"fraction", `{"position": {"x": 1, "y": 2}, "frame": 1.5}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			save := `{"version": 6, "trees": [` + tt.tree + `]}`
+			_, err := DecodeSave([]byte(save))
+			if err == nil || !strings.Contains(err.Error(), "tree 0: bad frame") {
+				t.Errorf("DecodeSave = %v, want a bad frame error", err)
+			}
+		})
+	}
+}
+
+func TestLoadMapEdits(t *testing.T) {
+	m, err := tilemap.Load(res.FS, "maps/farm.tmj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := NewWorld(Config{ViewWidth: 1920, Items: testItems(t), Map: m})
+	// Tiles on both sides of a chunk border, so the edit refits tiles in
+	// the next chunk too.
+	chunks := []tilemap.ChunkCoord{{X: 0, Y: 0}, {X: 1, Y: 0}}
+	tiles := func() []map[string]*tilemap.Chunk {
+		var all []map[string]*tilemap.Chunk
+		for _, c := range chunks {
+			all = append(all, w.copyTiles(c))
+		}
+		return all
+	}
+
+	fresh, before := w.Save(), tiles()
+	for x := 14; x <= 16; x++ {
+		if err := w.PaintTile("Soil", x, 10); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.EraseTile("Grass", 17, 10); err != nil {
+		t.Fatal(err)
+	}
+	data, err := EncodeSave(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := tiles()
+	if reflect.DeepEqual(edited, before) {
+		t.Fatal("painting changed no tiles")
+	}
+
+	w.Load(fresh)
+	if !reflect.DeepEqual(tiles(), before) {
+		t.Error("loading a save from before the edits didn't undo them")
+	}
+	d, err := DecodeSave(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Load(d)
+	if !reflect.DeepEqual(tiles(), edited) {
+		t.Error("loading a save with edits didn't redo them")
+	}
+}
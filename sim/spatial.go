@@ -0,0 +1,370 @@
+package sim
+
+import (
+	"cmp"
+	"iter"
+	"math"
+	"slices"
+)
+
+// SpatialCellSize is the side of a spatial hash cell, in pixels. It's a bit
+// more than the reach of the player's interactions and of crystals, so most
+// queries only look at the few cells around them.
+const SpatialCellSize = 256
+
+// SpatialHash finds entities by position without looking at every one. It
+// sorts them into square cells, and queries only visit the cells they
+// overlap. Entities are identified by an int the caller picks, unique among
+// them.
+//
+// Queries visit cells row by row and each cell's entities in the order they
+// were put there, so the same changes always give the same results in the
+// same order, which keeps the simulation deterministic.
+type SpatialHash struct {
+	cellSize float32
+	cells    map[spatialCell][]spatialEntry
+	count    int
+}
+
+type spatialCell struct {
+	X, Y int32
+}
+
+type spatialEntry struct {
+	id  int
+	pos Vec2
+}
+
+// NewSpatialHash returns an empty hash with cells cellSize pixels square.
+func NewSpatialHash(cellSize float32) *SpatialHash {
+	return &SpatialHash{
+		cellSize: cellSize,
+		cells:    make(map[spatialCell][]spatialEntry),
+	}
+}
+
+// Len returns how many entities are in the hash.
+func (h *SpatialHash) Len() int {
+	return h.count
+}
+
+// Clear empties the hash. Cells keep their memory for the entities put back
+// in them, and cells that stayed empty since the last Clear are dropped, so
+// the hash doesn't grow with every place entities have ever been.
+func (h *SpatialHash) Clear() {
+	for c, entries := range h.cells {
+		if len(entries) == 0 {
+			delete(h.cells, c)
+		} else {
+			h.cells[c] = entries[:0]
+		}
+	}
+	h.count = 0
+}
+
+// Insert adds entity id at pos.
+func (h *SpatialHash) Insert(id int, pos Vec2) {
+	c := h.cellOf(pos)
+	h.cells[c] = append(h.cells[c], spatialEntry{id, pos})
+	h.count++
+}
+
+// Remove takes entity id, at pos, out of the hash, and reports whether it
+// was there.
+func (h *SpatialHash) Remove(id int, pos Vec2) bool {
+	c := h.cellOf(pos)
+	entries := h.cells[c]
+	i := slices.IndexFunc(entries, func(e spatialEntry) bool { return e.id == id })
+	if i < 0 {
+		return false
+	}
+	h.cells[c] = slices.Delete(entries, i, i+1)
+	h.count--
+	return true
+}
+
+// Move updates entity id for having moved from one position to another.
+// Moving within a cell keeps its place among the cell's entities; moving to
+// another cell puts it last there.
+func (h *SpatialHash) Move(id int, from, to Vec2) {
+	c := h.cellOf(from)
+	if c == h.cellOf(to) {
+		for i := range h.cells[c] {
+			if e := &h.cells[c][i]; e.id == id {
+				e.pos = to
+				return
+			}
+		}
+		return
+	}
+	if h.Remove(id, from) {
+		h.Insert(id, to)
+	}
+}
+
+func (h *SpatialHash) cellOf(pos Vec2) spatialCell {
+	return spatialCell{
+		X: int32(math.Floor(float64(pos.X / h.cellSize))),
+		Y: int32(math.Floor(float64(pos.Y / h.cellSize))),
+	}
+}
+
+// QueryRect yields the entities inside area, with their positions. The
+// left and top edges are inside, the right and bottom ones aren't.
+func (h *SpatialHash) QueryRect(area Rect) iter.Seq2[int, Vec2] {
+	return func(yield func(int, Vec2) bool) {
+		first := h.cellOf(Vec2{area.X, area.Y})
+		last := h.cellOf(Vec2{area.X + area.Width, area.Y + area.Height})
+		for y := first.Y; y <= last.Y; y++ {
+			for x := first.X; x <= last.X; x++ {
+				for _, e := range h.cells[spatialCell{x, y}] {
+					if e.pos.X >= area.X && e.pos.X < area.X+area.Width &&
+						e.pos.Y >= area.Y && e.pos.Y < area.Y+area.Height &&
+						!yield(e.id, e.pos) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+// QueryRadius yields the entities less than radius from center, with their
+// positions.
+func (h *SpatialHash) QueryRadius(center Vec2, radius float32) iter.Seq2[int, Vec2] {
+	return func(yield func(int, Vec2) bool) {
+		area := Rect{center.X - radius, center.Y - radius, 2 * radius, 2 * radius}
+		for id, pos := range h.QueryRect(area) {
+			dx, dy := pos.X-center.X, pos.Y-center.Y
+			if dx*dx+dy*dy < radius*radius && !yield(id, pos) {
+				return
+			}
+		}
+	}
+}
+
+// Nearest returns the entity closest to center, less than radius away, that
+// match accepts; a nil match accepts any. Of entities equally close, the one
+// with the lowest id wins, as it would scanning a slice in order. The search
+// works outward ring by ring of cells and stops once no cell left can hold
+// anything closer.
+func (h *SpatialHash) Nearest(center Vec2, radius float32, match func(id int) bool) (int, bool) {
+	nearest, nearestDist := -1, radius
+	c := h.cellOf(center)
+	visit := func(cell spatialCell) {
+		for _, e := range h.cells[cell] {
+			distance := float32(math.Hypot(float64(center.X-e.pos.X), float64(center.Y-e.pos.Y)))
+			if distance > nearestDist || distance == nearestDist && (nearest < 0 || e.id > nearest) {
+				continue
+			}
+			if match == nil || match(e.id) {
+				nearest, nearestDist = e.id, distance
+			}
+		}
+	}
+
+	// Everything in ring r is at least r-1 cells from center.
+	for r := int32(0); float32(r-1)*h.cellSize < nearestDist; r++ {
+		for y := c.Y - r; y <= c.Y+r; y++ {
+			if y == c.Y-r || y == c.Y+r {
+				for x := c.X - r; x <= c.X+r; x++ {
+					visit(spatialCell{x, y})
+				}
+			} else {
+				visit(spatialCell{c.X - r, y})
+				if r > 0 {
+					visit(spatialCell{c.X + r, y})
+				}
+			}
+		}
+	}
+	return nearest, nearest >= 0
+}
+
+// spatialIndex keeps a SpatialHash of one of the world's entity slices. It
+// identifies entities by a key handed out as they're added, so they keep it
+// when others are removed or move in the slice. Keys only grow, so the
+// lowest key is the oldest entity. Trees stay in the order they were added,
+// so a tree is found by binary search; world items are swap-removed and
+// found through World.itemSlots.
+//
+// Adding, removing and moving entities updates the hash as it goes. Changes
+// to many at once, like loading a save, mark it stale instead, and the next
+// query rebuilds it.
+type spatialIndex struct {
+	hash    *SpatialHash
+	stale   bool
+	version int // Counts rebuilds, so anything worked out from queries knows to start over
+	nextKey int
+}
+
+// get returns the hash of n entities, rebuilding it first if it's stale.
+func (ix *spatialIndex) get(n int, entity func(i int) (key int, pos Vec2)) *SpatialHash {
+	if ix.hash == nil {
+		ix.hash = NewSpatialHash(SpatialCellSize)
+		ix.stale = true
+	}
+	if ix.stale {
+		ix.hash.Clear()
+		for i := range n {
+			ix.hash.Insert(entity(i))
+		}
+		ix.stale = false
+		ix.version++
+	}
+	return ix.hash
+}
+
+// live reports whether the hash is kept up to date change by change.
+func (ix *spatialIndex) live() bool {
+	return ix.hash != nil && !ix.stale
+}
+
+// add hands out the key for an entity appended at pos.
+func (ix *spatialIndex) add(pos Vec2) int {
+	ix.nextKey++
+	if ix.live() {
+		ix.hash.Insert(ix.nextKey, pos)
+	}
+	return ix.nextKey
+}
+
+func (ix *spatialIndex) remove(key int, pos Vec2) {
+	if ix.live() {
+		ix.hash.Remove(key, pos)
+	}
+}
+
+func (ix *spatialIndex) move(key int, from, to Vec2) {
+	if ix.live() && from != to {
+		ix.hash.Move(key, from, to)
+	}
+}
+
+// itemIndex returns the spatial hash of WorldItems, by key.
+func (w *World) itemIndex() *SpatialHash {
+	return w.items.get(len(w.WorldItems), func(i int) (int, Vec2) {
+		return w.WorldItems[i].key, w.WorldItems[i].Position
+	})
+}
+
+// treeIndex returns the spatial hash of Trees, by key.
+func (w *World) treeIndex() *SpatialHash {
+	return w.trees.get(len(w.Trees), func(i int) (int, Vec2) {
+		return w.Trees[i].key, w.Trees[i].Position
+	})
+}
+
+// itemAt returns the index in WorldItems of the item with key.
+func (w *World) itemAt(key int) int {
+	return w.itemSlots[key]
+}
+
+// treeAt returns the index in Trees of the tree with key.
+func (w *World) treeAt(key int) int {
+	i, _ := slices.BinarySearchFunc(w.Trees, key, func(t Tree, key int) int { return cmp.Compare(t.key, key) })
+	return i
+}
+
+// addWorldItem puts item in the world as it is.
+func (w *World) addWorldItem(item WorldItem) {
+	item.key = w.items.add(item.Position)
+	w.itemSlots[item.key] = len(w.WorldItems)
+	w.WorldItems = append(w.WorldItems, item)
+	if item.Motion != nil || item.PickupDelay > 0 {
+		w.tossed = append(w.tossed, item.key)
+	}
+	w.crystalChanged(&item, item.Position)
+}
+
+// removeWorldItem takes world item i out of the world. The last item takes
+// its place.
+func (w *World) removeWorldItem(i int) {
+	item := &w.WorldItems[i]
+	w.items.remove(item.key, item.Position)
+	w.crystalChanged(item, item.Position)
+	delete(w.itemSlots, item.key)
+
+	last := len(w.WorldItems) - 1
+	if i != last {
+		w.WorldItems[i] = w.WorldItems[last]
+		w.itemSlots[w.WorldItems[i].key] = i
+	}
+	w.WorldItems[last] = WorldItem{}
+	w.WorldItems = w.WorldItems[:last]
+}
+
+// clearWorldItems takes every item out of the world, leaving the index to
+// be rebuilt.
+func (w *World) clearWorldItems() {
+	w.items.stale = true
+	w.WorldItems = w.WorldItems[:0]
+	clear(w.itemSlots)
+	w.tossed = w.tossed[:0]
+	w.pulled = w.pulled[:0]
+}
+
+// worldItemMoved updates the index for world item i having moved from
+// from.
+func (w *World) worldItemMoved(i int, from Vec2) {
+	item := &w.WorldItems[i]
+	if from == item.Position {
+		return
+	}
+	w.items.move(item.key, from, item.Position)
+	w.crystalChanged(item, from)
+	w.crystalChanged(item, item.Position)
+}
+
+// addTree puts tree in the world.
+func (w *World) addTree(tree Tree) {
+	tree.key = w.trees.add(tree.Position)
+	w.Trees = append(w.Trees, tree)
+}
+
+// removeTree takes tree i out of the world.
+func (w *World) removeTree(i int) {
+	w.trees.remove(w.Trees[i].key, w.Trees[i].Position)
+	w.Trees = slices.Delete(w.Trees, i, i+1)
+}
+
+// removeTrees takes the trees at indices, which are in order, out of the
+// world. The rest keep their order.
+func (w *World) removeTrees(indices []int) {
+	if len(indices) == 0 {
+		return
+	}
+	live := indices[0]
+	for j, i := range indices {
+		w.trees.remove(w.Trees[i].key, w.Trees[i].Position)
+		next := len(w.Trees)
+		if j+1 < len(indices) {
+			next = indices[j+1]
+		}
+		live += copy(w.Trees[live:], w.Trees[i+1:next])
+	}
+	clear(w.Trees[live:])
+	w.Trees = w.Trees[:live]
+}
+
+// ItemsIn returns the indices in WorldItems of the items whose position is
+// inside area, in order.
+func (w *World) ItemsIn(area Rect) []int {
+	var indices []int
+	for key := range w.itemIndex().QueryRect(area) {
+		indices = append(indices, w.itemAt(key))
+	}
+	slices.Sort(indices)
+	return indices
+}
+
+// TreesIn returns the indices in Trees of the trees whose base is inside
+// area, in order, so trees that overlap draw the same way every frame.
+func (w *World) TreesIn(area Rect) []int {
+	var indices []int
+	for key := range w.treeIndex().QueryRect(area) {
+		indices = append(indices, w.treeAt(key))
+	}
+	slices.Sort(indices)
+	return indices
+}
@@ -0,0 +1,240 @@
+package sim
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"main/res"
+	"main/tilemap"
+)
+
+// The spatial index has to keep interaction, culling and growth cheap with
+// tens of thousands of things lying around. Entities are scattered one per
+// benchSpacing pixels square on average, about one every four tiles at scale
+// 3, so more of them cover more ground, as they would in a world explored
+// further; 50,000 of them cover 15 by 15 chunks.
+const benchSpacing = 100
+
+var benchSizes = []int{1_000, 10_000, 50_000}
+
+// benchPositions returns n positions scattered over the ground n entities
+// cover.
+func benchPositions(n int) []Vec2 {
+	return benchScatter(1, n, n)
+}
+
+// benchScatter returns count positions scattered over the ground n entities
+// cover, different for each seed.
+func benchScatter(seed int64, count, n int) []Vec2 {
+	side := float32(math.Sqrt(float64(n))) * benchSpacing
+	r := rand.New(rand.NewSource(seed))
+	pos := make([]Vec2, count)
+	for i := range pos {
+		pos[i] = Vec2{r.Float32() * side, r.Float32() * side}
+	}
+	return pos
+}
+
+func benchHash(pos []Vec2) *SpatialHash {
+	h := NewSpatialHash(SpatialCellSize)
+	for i, p := range pos {
+		h.Insert(i, p)
+	}
+	return h
+}
+
+// benchCenters are where queries are made from among n entities, cycled
+// through so they don't all hit the same cells.
+func benchCenters(n int) []Vec2 {
+	return benchScatter(2, 256, n)
+}
+
+func BenchmarkSpatialHashRebuild(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			pos := benchPositions(n)
+			h := benchHash(pos)
+			b.ResetTimer()
+			for range b.N {
+				h.Clear()
+				for i, p := range pos {
+					h.Insert(i, p)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSpatialHashQueryRadius(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			h := benchHash(benchPositions(n))
+			centers := benchCenters(n)
+			b.ResetTimer()
+			for i := range b.N {
+				for range h.QueryRadius(centers[i%len(centers)], BoostRadius) {
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSpatialHashQueryRect(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			h := benchHash(benchPositions(n))
+			centers := benchCenters(n)
+			b.ResetTimer()
+			for i := range b.N {
+				c := centers[i%len(centers)]
+				// About a screen's worth
+				for range h.QueryRect(Rect{c.X - 800, c.Y - 450, 1600, 900}) {
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkNearest compares the index with scanning every entity, as the
+// pickup and chop queries used to.
+func BenchmarkNearest(b *testing.B) {
+	for _, n := range benchSizes {
+		pos := benchPositions(n)
+		centers := benchCenters(n)
+		b.Run(fmt.Sprintf("hash/%d", n), func(b *testing.B) {
+			h := benchHash(pos)
+			b.ResetTimer()
+			for i := range b.N {
+				h.Nearest(centers[i%len(centers)], InteractionRadius, nil)
+			}
+		})
+		b.Run(fmt.Sprintf("linear/%d", n), func(b *testing.B) {
+			for i := range b.N {
+				center := centers[i%len(centers)]
+				nearest, nearestDist := -1, float32(InteractionRadius)
+				for j, p := range pos {
+					distance := float32(math.Hypot(float64(center.X-p.X), float64(center.Y-p.Y)))
+					if distance < nearestDist {
+						nearest, nearestDist = j, distance
+					}
+				}
+				_ = nearest
+			}
+		})
+	}
+}
+
+// BenchmarkStep steps a world crowded with items and as many trees, mostly
+// mature like a generated forest's. A few items are crystals and a few trees
+// are still growing. Steps have to stay well under a sixtieth of a second to
+// keep up with the tick rate.
+func BenchmarkStep(b *testing.B) {
+	items, err := LoadItems(res.FS)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			w := NewWorld(Config{Seed: 1, ViewWidth: 1280, CloudWidth: 2000, Items: items})
+			for i, p := range benchPositions(n) {
+				item := ItemID("pinecone")
+				if i%10 == 0 {
+					item = "crystal"
+				}
+				w.addWorldItem(WorldItem{Item: item, Count: 1, Position: p, PrevPosition: p})
+			}
+			for i, p := range benchScatter(3, n, n) {
+				stage := StageMature
+				if i%10 == 0 {
+					stage = StageSapling
+				}
+				w.SpawnTree(p, stage)
+			}
+			// The first step builds the indexes and works out every growing
+			// tree's boost, which a loaded world only does once.
+			w.Step(Inputs{}, 1.0/60)
+			b.ResetTimer()
+			for range b.N {
+				w.Step(Inputs{MoveX: 1}, 1.0/60)
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N), "ns/step")
+		})
+	}
+}
+
+// checkItemIndex fails unless every world item can be found by its key and
+// by its position.
+func checkItemIndex(t *testing.T, w *World) {
+	t.Helper()
+	h := w.itemIndex()
+	if h.Len() != len(w.WorldItems) || len(w.itemSlots) != len(w.WorldItems) {
+		t.Fatalf("%d items, %d in the hash and %d slots", len(w.WorldItems), h.Len(), len(w.itemSlots))
+	}
+	for i, item := range w.WorldItems {
+		if w.itemAt(item.key) != i {
+			t.Fatalf("item %d has key %d, which finds item %d", i, item.key, w.itemAt(item.key))
+		}
+		found := false
+		for key := range h.QueryRadius(item.Position, 1) {
+			found = found || key == item.key
+		}
+		if !found {
+			t.Fatalf("item %d at %v isn't in the hash there", i, item.Position)
+		}
+	}
+}
+
+func TestItemIndexChunks(t *testing.T) {
+	m, err := tilemap.Load(res.FS, "maps/world.tmj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := NewWorld(Config{Seed: 1, ViewWidth: 1280, Items: testItems(t), Map: m})
+	if !w.Generated() {
+		t.Fatal("world.tmj isn't generated")
+	}
+	start := w.Player.Center()
+
+	// A few items in every loaded chunk, and some in the air over them.
+	r := rand.New(rand.NewSource(1))
+	for c := range w.gen.loaded {
+		area := w.chunkArea(c)
+		for range 5 {
+			p := Vec2{area.X + 1 + r.Float32()*(area.Width-2), area.Y + 1 + r.Float32()*(area.Height-2)}
+			w.addWorldItem(WorldItem{Item: "pinecone", Count: 1, Position: p, PrevPosition: p})
+		}
+	}
+	for i := range 10 {
+		w.tossItem(WorldItem{Item: "crystal", Count: 1}, start, Vec2{start.X + float32(i)*40 - 200, start.Y + 150})
+	}
+	n := len(w.WorldItems)
+	held := w.Inventory.Count("pinecone") + w.Inventory.Count("crystal")
+	checkItemIndex(t, w)
+
+	// Far enough that every chunk with items unloads.
+	w.Player.Teleport(Vec2{start.X + 100_000, start.Y})
+	w.Step(Inputs{}, 1.0/60)
+	checkItemIndex(t, w)
+	if len(w.WorldItems) != 0 {
+		t.Errorf("%d items left after unloading their chunks", len(w.WorldItems))
+	}
+
+	// Coming back brings them all back, the tossed ones landed, and the
+	// player collects those around them.
+	w.Player.Teleport(start)
+	w.Step(Inputs{}, 1.0/60)
+	checkItemIndex(t, w)
+	for range 120 {
+		w.Step(Inputs{}, 1.0/60)
+	}
+	checkItemIndex(t, w)
+	total := w.Inventory.Count("pinecone") + w.Inventory.Count("crystal") - held
+	for _, item := range w.WorldItems {
+		total += item.Count
+	}
+	if total != n || len(w.tossed) != 0 {
+		t.Errorf("got %d items back and %d still tossed, want %d and none", total, len(w.tossed), n)
+	}
+}
@@ -0,0 +1,199 @@
+package sim
+
+import (
+	"math"
+	"slices"
+)
+
+const (
+	// TossSpeed is how fast a dropped item leaves the ground, in pixels per
+	// second, and ItemGravity pulls it back down.
+	TossSpeed   float32 = 240
+	ItemGravity float32 = 1200
+
+	// BounceRestitution is the share of its falling speed an item keeps when
+	// it bounces. Bounces slower than SettleSpeed end the toss.
+	BounceRestitution float32 = 0.35
+	SettleSpeed       float32 = 60
+
+	// PickupCooldown is how long a dropped item ignores the player, in
+	// seconds, so it isn't pulled straight back in.
+	PickupCooldown float32 = 1.5
+
+	// Items at rest within MagnetRadius of the player float toward them,
+	// faster as they get closer, and are collected within CollectRadius.
+	MagnetRadius  float32 = 64
+	MagnetSpeed   float32 = 480
+	CollectRadius float32 = 16
+)
+
+// ItemMotion is an item in the air after being tossed.
+type ItemMotion struct {
+	Velocity Vec2    `json:"velocity"` // Across the ground, in pixels per second
+	Height   float32 `json:"height"`   // Above the ground
+	VelZ     float32 `json:"velZ"`     // Upward speed
+}
+
+// InterpolatedPosition blends the previous and current ground position and
+// height. alpha is how far the renderer is between the last step and the
+// next one.
+func (item *WorldItem) InterpolatedPosition(alpha float32) (Vec2, float32) {
+	height := float32(0)
+	if item.Motion != nil {
+		height = item.PrevHeight + (item.Motion.Height-item.PrevHeight)*alpha
+	}
+	return Lerp(item.PrevPosition, item.Position, alpha), height
+}
+
+// tossItem throws an item from from so that its first bounce is at to.
+func (w *World) tossItem(item WorldItem, from, to Vec2) {
+	flight := 2 * TossSpeed / ItemGravity
+	item.Position = from
+	item.PrevPosition = from
+	item.Motion = &ItemMotion{
+		Velocity: Vec2{(to.X - from.X) / flight, (to.Y - from.Y) / flight},
+		VelZ:     TossSpeed,
+	}
+	item.PickupDelay = PickupCooldown
+	w.addWorldItem(item)
+}
+
+// updateWorldItems moves tossed items and pulls those near the player
+// toward them. Items lying still are left alone.
+func (w *World) updateWorldItems(dt float32) {
+	// Items the magnet moved last step have stopped, unless it moves them
+	// again below.
+	for _, key := range w.pulled {
+		if i, ok := w.itemSlots[key]; ok {
+			w.WorldItems[i].PrevPosition = w.WorldItems[i].Position
+		}
+	}
+	w.pulled = w.pulled[:0]
+
+	w.updateTossed(dt)
+
+	// The keys are gathered first, since pulling items changes the index.
+	// Oldest first, so which ones fit in the inventory doesn't depend on
+	// how the index is laid out.
+	var near []int
+	for key := range w.itemIndex().QueryRadius(w.Player.Center(), MagnetRadius) {
+		near = append(near, key)
+	}
+	slices.Sort(near)
+	for _, key := range near {
+		i := w.itemAt(key)
+		item := &w.WorldItems[i]
+		if item.Motion != nil {
+			continue
+		}
+		from := item.Position
+		if w.pullItem(item, dt) {
+			w.removeWorldItem(i)
+			continue
+		}
+		if item.Position != from {
+			w.worldItemMoved(i, from)
+			w.pulled = append(w.pulled, key)
+		}
+	}
+}
+
+// updateTossed moves tossed items through the air and counts down their
+// pickup delay, dropping them from w.tossed once they're at rest and can be
+// picked up.
+func (w *World) updateTossed(dt float32) {
+	var landed []WorldItem
+	kept := 0
+	for _, key := range w.tossed {
+		i, ok := w.itemSlots[key]
+		if !ok {
+			continue // Picked up or unloaded
+		}
+		item := &w.WorldItems[i]
+		item.PickupDelay = max(item.PickupDelay-dt, 0)
+
+		m := item.Motion
+		if m == nil {
+			if item.PickupDelay > 0 {
+				w.tossed[kept] = key
+				kept++
+			}
+			continue
+		}
+		item.PrevPosition = item.Position
+		item.PrevHeight = m.Height
+		atRest := w.flyItem(item, dt)
+		w.worldItemMoved(i, item.PrevPosition)
+		if atRest {
+			landed = append(landed, *item)
+			w.removeWorldItem(i)
+			continue
+		}
+		w.tossed[kept] = key
+		kept++
+	}
+	w.tossed = w.tossed[:kept]
+
+	// Land in the order they were tossed, so merges don't depend on where
+	// the items were in WorldItems.
+	for _, item := range landed {
+		w.landItem(item)
+	}
+}
+
+// flyItem moves a tossed item through the air and reports whether it has
+// come to rest.
+func (w *World) flyItem(item *WorldItem, dt float32) bool {
+	m := item.Motion
+	item.Position.X += m.Velocity.X * dt
+	item.Position.Y += m.Velocity.Y * dt
+	m.VelZ -= ItemGravity * dt
+	m.Height += m.VelZ * dt
+	if m.Height > 0 {
+		return false
+	}
+
+	m.Height = 0
+	m.VelZ = -m.VelZ * BounceRestitution
+	m.Velocity.X *= 0.5
+	m.Velocity.Y *= 0.5
+	if m.VelZ >= SettleSpeed {
+		return false
+	}
+	item.Motion = nil
+	inventoryLog.Debug("item landed", "item", item.Item, "pos", item.Position)
+	return true
+}
+
+// pullItem draws an item at rest toward the player if it's close enough and
+// has room in the inventory, and reports whether it was collected whole.
+func (w *World) pullItem(item *WorldItem, dt float32) bool {
+	if item.PickupDelay > 0 {
+		return false
+	}
+	playerCenter := w.Player.Center()
+	dx, dy := playerCenter.X-item.Position.X, playerCenter.Y-item.Position.Y
+	distance := float32(math.Hypot(float64(dx), float64(dy)))
+	if distance >= MagnetRadius {
+		return false
+	}
+	room := w.Inventory.Room(item.Item)
+	if room == 0 {
+		return false
+	}
+
+	if distance > CollectRadius {
+		speed := MagnetSpeed * (1 - distance/MagnetRadius/2)
+		step := min(speed*dt, distance)
+		item.Position.X += dx / distance * step
+		item.Position.Y += dy / distance * step
+		return false
+	}
+
+	n := min(room, item.Count)
+	w.Inventory.AddItem(item.Item, n)
+	item.Count -= n
+	w.crystalChanged(item, item.Position)
+	inventoryLog.Debug("collected item", "item", item.Item, "count", n)
+	return item.Count == 0
+}
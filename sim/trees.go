@@ -0,0 +1,207 @@
+package sim
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// TreeStage is how far a tree has grown. Each stage is one frame of the pine
+// tree sprite sheet.
+type TreeStage int
+
+const (
+	StageSeedling TreeStage = iota
+	StageSapling
+	StageYoung
+	StageMature
+
+	TreeStages // Number of stages, and of frames in the sprite sheet
+)
+
+var treeStageNames = [TreeStages]string{
+	StageSeedling: "seedling",
+	StageSapling:  "sapling",
+	StageYoung:    "young",
+	StageMature:   "mature",
+}
+
+func (s TreeStage) String() string {
+	if s < 0 || s >= TreeStages {
+		return fmt.Sprintf("TreeStage(%d)", int(s))
+	}
+	return treeStageNames[s]
+}
+
+// ParseTreeStage accepts a stage name or its number.
+func ParseTreeStage(s string) (TreeStage, error) {
+	for i, name := range treeStageNames {
+		if s == name {
+			return TreeStage(i), nil
+		}
+	}
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 && n < int(TreeStages) {
+		return TreeStage(n), nil
+	}
+	return 0, fmt.Errorf("unknown tree stage %q", s)
+}
+
+// StageDurations is how many seconds of game time a tree spends in each
+// stage before the next; a mature tree stays mature.
+var StageDurations = [TreeStages - 1]float32{
+	StageSeedling: 30,
+	StageSapling:  60,
+	StageYoung:    90,
+}
+
+const (
+	// ConeInterval is how often a mature tree drops a seed, in seconds of
+	// game time.
+	ConeInterval float32 = 45
+
+	// A mature tree stops dropping seeds while MaxConesAroundTree of them
+	// lie within ConeAreaRadius of its base, so an untended forest doesn't
+	// bury itself. Seeds land between ConeDropMin and ConeDropMax away.
+	MaxConesAroundTree         = 3
+	ConeAreaRadius     float32 = 120
+	ConeDropMin        float32 = 40
+	ConeDropMax        float32 = 100
+)
+
+// Tree is a tree planted from a seed item, such as a pine cone.
+type Tree struct {
+	Position Vec2 // Base of the trunk
+	Seed     ItemID
+	Stage    TreeStage
+	Age      float32 // Seconds of growth since planting, or since felling for stumps
+	ConeTime float32 // Seconds toward the next dropped seed, once mature
+
+	Stump bool    // Felled, waiting to be dug up or to sprout
+	Hits  int     // Axe hits taken so far
+	Shake float32 // Seconds of shaking left from the last hit
+
+	Boost       float32 // Growth multiplier from nearby crystals, 1 for none
+	SparkleTime float32 // Toward the next sparkle while boosted
+
+	key          int     // Identifies it in the world's spatial index
+	boost        float32 // Growth multiplier crystals give, while boostVersion is current
+	boostVersion int     // Item index version boost was worked out at, 0 to work it out again
+}
+
+// stageStart returns the age at which a tree reaches stage.
+func stageStart(stage TreeStage) float32 {
+	var age float32
+	for s := StageSeedling; s < stage; s++ {
+		age += StageDurations[s]
+	}
+	return age
+}
+
+// StageProgress returns how far the tree is through its current stage, from
+// 0 to 1, or for a stump how close it is to sprouting. Mature trees are
+// always 1.
+func (t *Tree) StageProgress() float32 {
+	if t.Stump {
+		return t.Age / StumpRegrowTime
+	}
+	if t.Stage >= StageMature {
+		return 1
+	}
+	return (t.Age - stageStart(t.Stage)) / StageDurations[t.Stage]
+}
+
+func (w *World) updateTrees(dt float32) {
+	grow := dt * w.GrowthSpeed
+	for i := range w.Trees {
+		tree := &w.Trees[i]
+		tree.Shake = max(tree.Shake-dt, 0)
+		if tree.Stump {
+			w.updateStump(i, grow)
+			continue
+		}
+		// Crystals only hurry trees that are still growing.
+		tree.Boost = 1
+		if tree.Stage < StageMature {
+			tree.Boost = w.treeBoost(tree)
+			w.sparkle(tree, dt)
+		}
+		tree.Age += grow * tree.Boost
+
+		for tree.Stage < StageMature && tree.Age >= stageStart(tree.Stage+1) {
+			tree.Stage++
+			if tree.Stage == StageMature {
+				treesLog.Info("tree matured", "tree", i, "pos", tree.Position)
+			} else {
+				treesLog.Debug("tree grew", "tree", i, "stage", tree.Stage)
+			}
+		}
+		if tree.Stage < StageMature {
+			continue
+		}
+
+		tree.ConeTime += grow
+		if tree.ConeTime < ConeInterval {
+			continue
+		}
+		tree.ConeTime -= ConeInterval
+		w.dropCone(tree)
+	}
+}
+
+// dropCone has a mature tree drop one of its seeds somewhere around its base,
+// unless enough already lie there.
+func (w *World) dropCone(tree *Tree) {
+	if _, ok := w.Config.Items.Get(tree.Seed); !ok {
+		return
+	}
+
+	nearby := 0
+	for key := range w.itemIndex().QueryRadius(tree.Position, ConeAreaRadius) {
+		if item := &w.WorldItems[w.itemAt(key)]; item.Item == tree.Seed {
+			nearby += item.Count
+		}
+	}
+	if nearby >= MaxConesAroundTree {
+		treesLog.Debug("tree has enough seeds around it", "pos", tree.Position, "seeds", nearby)
+		return
+	}
+
+	rng := w.RNG(StreamGameplay)
+	angle := rng.Float64() * 2 * math.Pi
+	distance := float64(ConeDropMin) + rng.Float64()*float64(ConeDropMax-ConeDropMin)
+	to := Vec2{
+		X: tree.Position.X + float32(math.Cos(angle)*distance),
+		Y: tree.Position.Y + float32(math.Sin(angle)*distance),
+	}
+	w.tossItem(WorldItem{Item: tree.Seed, Count: 1}, tree.Position, to)
+	treesLog.Debug("tree dropped a seed", "pos", tree.Position, "item", tree.Seed, "at", to)
+}
+
+// plantTree starts a seedling from seed at pos.
+func (w *World) plantTree(pos Vec2, seed ItemID) {
+	w.addTree(Tree{Position: pos, Seed: seed})
+}
+
+// SpawnTree plants a tree at pos that has already grown to the given stage,
+// grown from the first plantable item.
+func (w *World) SpawnTree(pos Vec2, stage TreeStage) {
+	stage = max(StageSeedling, min(stage, StageMature))
+	w.addTree(Tree{
+		Position: pos,
+		Seed:     w.treeSeed(),
+		Stage:    stage,
+		Age:      stageStart(stage),
+	})
+	treesLog.Debug("spawned tree", "pos", pos, "stage", stage)
+}
+
+// treeSeed returns the first plantable item, which trees that weren't
+// planted by the player are grown from.
+func (w *World) treeSeed() ItemID {
+	for _, def := range w.Config.Items.defs {
+		if def.HasTag(TagPlantable) {
+			return def.ID
+		}
+	}
+	return ""
+}
@@ -0,0 +1,229 @@
+// Package sim holds the game state and rules for Konno. It does not import
+// raylib, so the game logic can run headless in tests and tools; the raylib
+// front end in package main only polls input and draws what is in a World.
+package sim
+
+import (
+	"cmp"
+	"slices"
+
+	"main/tilemap"
+)
+
+// Vec2 is a point or offset in world space.
+type Vec2 struct {
+	X float32 `json:"x"`
+	Y float32 `json:"y"`
+}
+
+// Lerp returns the point t of the way from a to b.
+func Lerp(a, b Vec2, t float32) Vec2 {
+	return Vec2{a.X + (b.X-a.X)*t, a.Y + (b.Y-a.Y)*t}
+}
+
+// Rect is an axis-aligned rectangle in world space.
+type Rect struct {
+	X, Y, Width, Height float32
+}
+
+// Color is an RGBA color the front end can hand straight to the renderer.
+type Color struct {
+	R, G, B, A uint8
+}
+
+// Inputs is everything the player asked for during one step.
+type Inputs struct {
+	// MoveX and MoveY run from -1 to 1; keys give whole steps and analog
+	// sticks anything in between. Negative Y is up.
+	MoveX float32 `json:"moveX,omitempty"`
+	MoveY float32 `json:"moveY,omitempty"`
+
+	Drop     bool `json:"drop,omitempty"`     // Drop one of the selected item
+	Use      bool `json:"use,omitempty"`      // Use the selected item, like swinging an axe
+	Interact bool `json:"interact,omitempty"` // Pick up the nearest item
+	Plant    bool `json:"plant,omitempty"`
+	Splash   bool `json:"splash,omitempty"`
+
+	// Select picks an inventory slot, counting from 1; 0 leaves the
+	// selection alone. Scroll moves the selection by that many slots.
+	Select int `json:"select,omitempty"`
+	Scroll int `json:"scroll,omitempty"`
+}
+
+// Latch folds the inputs polled for a newer frame into in. Held directions
+// follow the newest frame, while one-shot actions stay set until a step
+// consumes them, so a key press is never lost when a frame runs no steps.
+func (in Inputs) Latch(next Inputs) Inputs {
+	next.Drop = next.Drop || in.Drop
+	next.Use = next.Use || in.Use
+	next.Interact = next.Interact || in.Interact
+	next.Select = cmp.Or(next.Select, in.Select)
+	next.Scroll += in.Scroll
+	next.Plant = next.Plant || in.Plant
+	next.Splash = next.Splash || in.Splash
+	return next
+}
+
+// Held returns in with the one-shot actions cleared, leaving only the
+// movement that is still held.
+func (in Inputs) Held() Inputs {
+	return Inputs{MoveX: in.MoveX, MoveY: in.MoveY}
+}
+
+// Config holds the values the world needs from the outside, such as the size
+// of the view the clouds wrap around. Two worlds built from the same Config
+// and fed the same inputs stay identical.
+type Config struct {
+	Seed       int64   `json:"seed"`
+	ViewWidth  float32 `json:"viewWidth"`
+	CloudWidth float32 `json:"cloudWidth"`
+
+	// Items is loaded from the res tree rather than recorded, since it
+	// ships with the game.
+	Items *ItemRegistry `json:"-"`
+
+	// Map is the tile map whose solid tiles block movement, loaded from
+	// MapPath in the res tree. Only the path is recorded.
+	MapPath string       `json:"map,omitempty"`
+	Map     *tilemap.Map `json:"-"`
+}
+
+// World is the complete game state.
+type World struct {
+	Config Config
+
+	Player Player
+
+	Time  float32 // Seconds simulated so far
+	Ticks int     // Steps simulated so far
+
+	Inventory Inventory
+
+	WorldItems []WorldItem // Items lying in the world
+
+	Trees       []Tree
+	GrowthSpeed float32 // Multiplier on tree growth and seed drops, for testing
+
+	items, trees spatialIndex // Where WorldItems and Trees are, by index
+	itemSlots    map[int]int  // Where each world item is in WorldItems, by key
+
+	tossed []int // Keys of tossed items still in the air or not yet collectable
+	pulled []int // Keys of items the magnet moved last step
+
+	Props []Prop
+
+	gen *worldGen // Nil unless the map is generated
+
+	// mapEdits holds the chunks of a hand-made map that were edited, as they
+	// were before the first edit, by layer, so Load can put them back.
+	mapEdits map[tilemap.ChunkCoord]map[string]*tilemap.Chunk
+
+	Particles []Particle
+
+	CloudsLayer1 []Vec2 // Farthest, slowest
+	CloudsLayer2 []Vec2 // Middle
+	CloudsLayer3 []Vec2 // Closest, fastest
+
+	rngs rngs
+}
+
+// NewWorld returns a world in its starting state.
+func NewWorld(cfg Config) *World {
+	w := &World{
+		Config: cfg,
+		Player: Player{
+			Src:      Rect{0, 0, 48, 48},
+			Dest:     Rect{200, 200, 100, 100},
+			PrevDest: Rect{200, 200, 100, 100},
+		},
+		WorldItems:  make([]WorldItem, 0),
+		Trees:       make([]Tree, 0),
+		GrowthSpeed: 1,
+		Props:       slices.Clone(StartingProps),
+		Particles:   make([]Particle, 0),
+		itemSlots:   make(map[int]int),
+		mapEdits:    make(map[tilemap.ChunkCoord]map[string]*tilemap.Chunk),
+		rngs:        newRNGs(cfg.Seed),
+	}
+	if w.Config.Items == nil {
+		w.Config.Items = &ItemRegistry{}
+	}
+	w.Inventory = NewInventory(InventorySize, w.Config.Items)
+	for _, def := range w.Config.Items.defs {
+		if def.Start > 0 {
+			w.Inventory.AddItem(def.ID, def.Start)
+		}
+	}
+	if m := cfg.Map; m != nil && m.Properties.Bool(tilemap.PropGenerate) {
+		gen, err := newWorldGen(m)
+		if err != nil {
+			worldLog.Error("map can't be generated, playing it as it is", "err", err)
+		} else {
+			w.gen = gen
+			gen.reseed(cfg.Seed)
+			w.updateChunks()
+		}
+	}
+	w.initClouds()
+	return w
+}
+
+// Step advances the world by dt seconds using the given inputs. The front
+// end calls it with a fixed dt so the game runs at the same speed whatever
+// the frame rate.
+func (w *World) Step(in Inputs, dt float32) {
+	w.handleInputs(in)
+
+	w.updatePlayer(dt)
+	w.updateChunks()
+	w.updateWorldItems(dt)
+	w.updateTrees(dt)
+	w.updateParticles(dt)
+	w.updateClouds(dt)
+
+	w.Time += dt
+	w.Ticks++
+}
+
+func (w *World) handleInputs(in Inputs) {
+	w.Player.movePressed(in)
+
+	if in.Select > 0 {
+		w.Inventory.Select(in.Select - 1)
+	}
+	if in.Scroll != 0 {
+		w.Inventory.Select(w.Inventory.Selected + in.Scroll)
+	}
+
+	if in.Drop {
+		inputLog.Debug("drop")
+		w.dropSelected()
+	}
+
+	if in.Use {
+		inputLog.Debug("use")
+		w.useSelected()
+	}
+
+	if in.Plant {
+		inputLog.Debug("plant")
+		if seed, ok := w.takeNearbyPlantable(); ok {
+			treesLog.Info("planted tree", "pos", seed.Position, "item", seed.Item)
+			w.plantTree(seed.Position, seed.Item)
+		} else {
+			treesLog.Debug("nothing to plant", "player", w.Player.Center())
+		}
+	}
+
+	if in.Interact {
+		inputLog.Debug("interact")
+		w.interact()
+	}
+
+	if in.Splash {
+		inputLog.Debug("splash")
+		// Create splash at player position
+		playerCenter := w.Player.Center()
+		w.CreateSplashEffect(playerCenter.X, playerCenter.Y)
+	}
+}
@@ -0,0 +1,320 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"main/tilemap"
+)
+
+// Biome is the kind of land a generated world has at a tile.
+type Biome int
+
+const (
+	BiomeMeadow Biome = iota // Open grass with flowers
+	BiomeForest              // Grass thick with pine trees and mushrooms
+	BiomePond                // Open water with lily pads
+	BiomeHills               // Raised ground behind cliffs
+
+	biomeCount
+)
+
+var biomeNames = [biomeCount]string{
+	BiomeMeadow: "meadow",
+	BiomeForest: "forest",
+	BiomePond:   "pond",
+	BiomeHills:  "hills",
+}
+
+func (b Biome) String() string {
+	if b < 0 || b >= biomeCount {
+		return fmt.Sprintf("Biome(%d)", int(b))
+	}
+	return biomeNames[b]
+}
+
+// The layers a generated world's template map needs. Ground is autotiled
+// with the grass terrain and covers everything but ponds, water lies under
+// the ponds and their shores, hills are autotiled over the ground, and
+// decorations are scattered over the lot.
+const (
+	LayerWater       = "Water"
+	LayerGround      = "Grass"
+	LayerHills       = "Hills"
+	LayerDecorations = "Decorations"
+)
+
+// World generation. Elevation noise digs ponds where it's low and raises
+// hills where it's high; moisture noise grows forests where it's wet. Noise
+// features span a few dozen tiles, so biomes come in patches the size of a
+// chunk or two.
+const (
+	ElevationScale = 28 // Tiles across the broadest hills and ponds
+	MoistureScale  = 40 // Tiles across the broadest forests
+
+	PondLevel   = -0.3 // Elevation below which there's water
+	HillLevel   = 0.4  // Elevation above which there are hills
+	ForestLevel = 0.02 // Moisture above which there's forest
+
+	// Within SpawnClearing tiles of SpawnTile the land flattens out into a
+	// meadow, fading back to the noise over as many tiles again, so a new
+	// world always starts on dry, open ground around the nest.
+	SpawnClearing = 12
+
+	ForestTreeDensity = 0.16 // Chance each forest tile has a tree
+	TreeJitter        = 12   // Pixels a generated tree strays from its tile's center
+)
+
+// SpawnTile is the tile the player starts on.
+var SpawnTile = [2]int{7, 7}
+
+// DecorationDensity is the chance a tile of each biome gets a decoration.
+var DecorationDensity = [biomeCount]float64{
+	BiomeMeadow: 0.08,
+	BiomeForest: 0.1,
+	BiomePond:   0.06,
+}
+
+// worldGen fills a generated world's map, and keeps track of which of its
+// chunks are loaded and which differ from what it would make.
+type worldGen struct {
+	m                                    *tilemap.Map
+	water, ground, hills, decorations    *tilemap.Layer
+	waterTile                            tilemap.GID
+	decorationTiles                      [biomeCount][]tilemap.GID
+	elevationSeed, moistureSeed, scatter int64
+
+	loaded map[tilemap.ChunkCoord]bool
+	edited map[tilemap.ChunkCoord]bool       // Loaded chunks whose tiles were changed
+	stored map[tilemap.ChunkCoord]SavedChunk // Unloaded chunks that were changed
+}
+
+// newWorldGen checks that m has what a generated world needs: its layers,
+// with terrains on the ground and hills, and a water tile in some tileset.
+// Decorations are the tiles with PropBiome set.
+func newWorldGen(m *tilemap.Map) (*worldGen, error) {
+	g := &worldGen{
+		m:      m,
+		loaded: make(map[tilemap.ChunkCoord]bool),
+		edited: make(map[tilemap.ChunkCoord]bool),
+		stored: make(map[tilemap.ChunkCoord]SavedChunk),
+	}
+
+	var errs []error
+	layer := func(name string, autotiled bool) *tilemap.Layer {
+		l := m.Layer(name)
+		switch {
+		case l == nil:
+			errs = append(errs, fmt.Errorf("no %q layer", name))
+		case autotiled && l.Terrain == nil:
+			errs = append(errs, fmt.Errorf("layer %q isn't autotiled", name))
+		}
+		return l
+	}
+	g.water = layer(LayerWater, false)
+	g.ground = layer(LayerGround, true)
+	g.hills = layer(LayerHills, true)
+	g.decorations = layer(LayerDecorations, false)
+
+	for _, ts := range m.Tilesets {
+		for id := range ts.TileCount {
+			gid := ts.FirstGID + tilemap.GID(id)
+			if g.waterTile == 0 && ts.Flag(id, tilemap.PropWater) {
+				g.waterTile = gid
+			}
+			name := m.TileProperties(gid).String(tilemap.PropBiome)
+			if name == "" {
+				continue
+			}
+			b, ok := parseBiome(name)
+			if !ok {
+				errs = append(errs, fmt.Errorf("tileset %q: tile %d is for unknown biome %q", ts.Name, id, name))
+				continue
+			}
+			g.decorationTiles[b] = append(g.decorationTiles[b], gid)
+		}
+	}
+	if g.waterTile == 0 {
+		errs = append(errs, errors.New("no tileset has a water tile"))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("generated world: %w", err)
+	}
+	return g, nil
+}
+
+func parseBiome(s string) (Biome, bool) {
+	for i, name := range biomeNames {
+		if s == name {
+			return Biome(i), true
+		}
+	}
+	return 0, false
+}
+
+// reseed points the generator at a world seed and forgets every chunk.
+func (g *worldGen) reseed(seed int64) {
+	g.elevationSeed = DeriveSeed(seed, "elevation")
+	g.moistureSeed = DeriveSeed(seed, "moisture")
+	g.scatter = DeriveSeed(seed, "scatter")
+
+	for _, l := range g.m.Layers {
+		l.Clear()
+	}
+	clear(g.loaded)
+	clear(g.edited)
+	clear(g.stored)
+}
+
+// biome decides the land at tile x, y.
+func (g *worldGen) biome(x, y int) Biome {
+	fx, fy := float64(x), float64(y)
+	elevation := fractalNoise(g.elevationSeed, fx, fy, ElevationScale, 4)
+	moisture := fractalNoise(g.moistureSeed, fx, fy, MoistureScale, 3)
+
+	spawn := math.Hypot(fx-float64(SpawnTile[0]), fy-float64(SpawnTile[1]))
+	wild := max(0, min((spawn-SpawnClearing)/SpawnClearing, 1))
+	elevation *= wild
+	moisture = lerp(-1, moisture, wild)
+
+	switch {
+	case elevation < PondLevel:
+		return BiomePond
+	case elevation > HillLevel:
+		return BiomeHills
+	case moisture > ForestLevel:
+		return BiomeForest
+	}
+	return BiomeMeadow
+}
+
+// open reports whether the tiles around x, y are all flat land, where trees
+// can grow without their canopies poking out of cliffs and ponds.
+func (g *worldGen) open(x, y int) bool {
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			if b := g.biome(x+dx, y+dy); b == BiomePond || b == BiomeHills {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// treeAt returns where the tree at tile x, y stands, if there is one.
+// tileW and tileH are the size of a tile in world pixels.
+func (g *worldGen) treeAt(x, y int, tileW, tileH float32) (Vec2, bool) {
+	if g.biome(x, y) != BiomeForest || unitHash(g.scatter, x, y) >= ForestTreeDensity || !g.open(x, y) {
+		return Vec2{}, false
+	}
+	h := hash2(g.scatter+1, x, y)
+	jitterX := float32(h&0xffff)/0xffff*2 - 1
+	jitterY := float32(h>>16&0xffff)/0xffff*2 - 1
+	return Vec2{
+		X: (float32(x)+0.5)*tileW + jitterX*TreeJitter,
+		Y: (float32(y)+0.5)*tileH + jitterY*TreeJitter,
+	}, true
+}
+
+// decorationAt returns the decoration scattered on tile x, y, or 0.
+// Lily pads only float clear of the shore, and nothing grows under a tree.
+func (g *worldGen) decorationAt(x, y int, b Biome, tree bool) tilemap.GID {
+	tiles := g.decorationTiles[b]
+	if len(tiles) == 0 || tree || unitHash(g.scatter+2, x, y) >= DecorationDensity[b] {
+		return 0
+	}
+	if b == BiomePond {
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				if g.biome(x+dx, y+dy) != BiomePond {
+					return 0
+				}
+			}
+		}
+	}
+
+	var total float64
+	for _, gid := range tiles {
+		total += g.probability(gid)
+	}
+	pick := unitHash(g.scatter+3, x, y) * total
+	for _, gid := range tiles {
+		pick -= g.probability(gid)
+		if pick < 0 {
+			return gid
+		}
+	}
+	return tiles[len(tiles)-1]
+}
+
+func (g *worldGen) probability(gid tilemap.GID) float64 {
+	ts, id, _ := g.m.Tileset(gid)
+	return ts.Probability(id)
+}
+
+// generateTiles fills chunk c of every generated layer.
+func (g *worldGen) generateTiles(c tilemap.ChunkCoord) {
+	tileW, tileH := g.m.TileSize()
+	x0, y0 := c.X*tilemap.ChunkSize, c.Y*tilemap.ChunkSize
+
+	// Biomes of the chunk and the ring around it, which shores need
+	const span = tilemap.ChunkSize + 2
+	var biomes [span * span]Biome
+	biomeAt := func(x, y int) Biome {
+		return biomes[(y-y0+1)*span+(x-x0+1)]
+	}
+	for i := range biomes {
+		biomes[i] = g.biome(x0-1+i%span, y0-1+i/span)
+	}
+
+	water, decorations := new(tilemap.Chunk), new(tilemap.Chunk)
+	for i := range water {
+		x, y := x0+i%tilemap.ChunkSize, y0+i/tilemap.ChunkSize
+		// Water reaches under the shore, where the grass edge is see-through.
+		for dy := -1; dy <= 1 && water[i] == 0; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				if biomeAt(x+dx, y+dy) == BiomePond {
+					water[i] = g.waterTile
+					break
+				}
+			}
+		}
+		_, tree := g.treeAt(x, y, tileW, tileH)
+		decorations[i] = g.decorationAt(x, y, biomeAt(x, y), tree)
+	}
+
+	g.water.SetChunk(c, emptyToNil(water))
+	g.decorations.SetChunk(c, emptyToNil(decorations))
+	g.ground.FillTerrain(c, func(x, y int) bool { return biomeAt(x, y) != BiomePond })
+	g.hills.FillTerrain(c, func(x, y int) bool { return biomeAt(x, y) == BiomeHills })
+}
+
+// emptyToNil returns nil for a chunk without tiles, so layers don't keep
+// chunks that draw nothing.
+func emptyToNil(chunk *tilemap.Chunk) *tilemap.Chunk {
+	if *chunk == (tilemap.Chunk{}) {
+		return nil
+	}
+	return chunk
+}
+
+// generateTrees returns the trees the generator grows in chunk c, all of
+// them mature and grown from seed.
+func (g *worldGen) generateTrees(c tilemap.ChunkCoord, seed ItemID) []Tree {
+	tileW, tileH := g.m.TileSize()
+	var trees []Tree
+	for i := range tilemap.ChunkSize * tilemap.ChunkSize {
+		x, y := c.X*tilemap.ChunkSize+i%tilemap.ChunkSize, c.Y*tilemap.ChunkSize+i/tilemap.ChunkSize
+		if pos, ok := g.treeAt(x, y, tileW, tileH); ok {
+			trees = append(trees, Tree{
+				Position: pos,
+				Seed:     seed,
+				Stage:    StageMature,
+				Age:      stageStart(StageMature),
+			})
+		}
+	}
+	return trees
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const statusDuration = 2.0 // Seconds a status message stays on screen
+
+var (
+	statusText  string
+	statusUntil float64
+)
+
+// showStatus flashes a short message at the top of the screen.
+func showStatus(text string) {
+	statusText = text
+	statusUntil = rl.GetTime() + statusDuration
+}
+
+func drawStatus() {
+	if statusText == "" || rl.GetTime() > statusUntil {
+		return
+	}
+
+	const fontSize = 30
+	width := rl.MeasureText(statusText, fontSize)
+	rl.DrawText(statusText, (screenWidth-width)/2, 20, fontSize, rl.Black)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+
+	"main/console"
+	"main/tilemap"
+)
+
+var mapPath = flag.String("map", "maps/world.tmj", "Tiled map to play on, inside the res/ tree")
+
+var (
+	worldMap *tilemap.Map // Nil if the map couldn't be loaded
+
+	tilesetTextures = make(map[*tilemap.Tileset]rl.Texture2D)
+)
+
+// loadWorldMap loads the -map file and the images of its tilesets. Anything
+// that goes wrong is returned; a map that can't be loaded at all leaves
+// worldMap nil and the ground bare.
+func loadWorldMap() []error {
+	m, err := tilemap.Load(resources, *mapPath)
+	if err != nil {
+		return []error{err}
+	}
+
+	var errs []error
+	for _, ts := range m.Tilesets {
+		tex, err := assets.File(ts.Image)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tileset %q: %w", ts.Name, err))
+		} else if ts.ImageWidth != 0 && (int(tex.Width) != ts.ImageWidth || int(tex.Height) != ts.ImageHeight) {
+			errs = append(errs, fmt.Errorf("tileset %q: %s is %dx%d but the tileset expects %dx%d",
+				ts.Name, ts.Image, tex.Width, tex.Height, ts.ImageWidth, ts.ImageHeight))
+		}
+		tilesetTextures[ts] = tex
+	}
+
+	worldMap = m
+	return errs
+}
+
+// mapTileSize is the size of a map tile in world pixels.
+func mapTileSize() (width, height float32) {
+	if worldMap == nil {
+		return 16, 16
+	}
+	return worldMap.TileSize()
+}
+
+// drawWorldMap draws the map's visible layers, bottom to top. Only the
+// chunks overlapping the visible area, given in world pixels, are drawn.
+func drawWorldMap(minX, minY, maxX, maxY float32) {
+	if worldMap == nil {
+		return
+	}
+
+	tileW, tileH := mapTileSize()
+	scale := worldMap.Scale()
+	first := tilemap.ChunkOf(int(math.Floor(float64(minX/tileW))), int(math.Floor(float64(minY/tileH))))
+	last := tilemap.ChunkOf(int(math.Floor(float64(maxX/tileW))), int(math.Floor(float64(maxY/tileH))))
+	now := time.Duration(rl.GetTime() * float64(time.Second))
+
+	for _, layer := range worldMap.Layers {
+		if !layer.Visible || layer.Opacity <= 0 {
+			continue
+		}
+		tint := rl.Fade(rl.White, layer.Opacity)
+
+		for cy := first.Y; cy <= last.Y; cy++ {
+			for cx := first.X; cx <= last.X; cx++ {
+				chunk := layer.Chunk(tilemap.ChunkCoord{X: cx, Y: cy})
+				if chunk == nil {
+					continue
+				}
+				for i, gid := range chunk {
+					ts, id, ok := worldMap.Tileset(gid)
+					if !ok {
+						continue
+					}
+					x, y, w, h := ts.Source(ts.Frame(id, now))
+					src := rl.NewRectangle(float32(x), float32(y), float32(w), float32(h))
+					dest := rl.NewRectangle(
+						float32(cx*tilemap.ChunkSize+i%tilemap.ChunkSize)*tileW,
+						float32(cy*tilemap.ChunkSize+i/tilemap.ChunkSize)*tileH,
+						float32(w)*scale,
+						float32(h)*scale,
+					)
+					drawTile(tilesetTextures[ts], src, dest, gid, tint)
+				}
+			}
+		}
+	}
+}
+
+// drawTile draws a tile from a tileset into dest, flipped the way its GID
+// says.
+func drawTile(tex rl.Texture2D, src, dest rl.Rectangle, gid tilemap.GID, tint rl.Color) {
+	flipH, flipV := gid&tilemap.FlipH != 0, gid&tilemap.FlipV != 0
+	var rotation float32
+	if gid&tilemap.FlipD != 0 {
+		// A diagonal flip is the tile mirrored top to bottom and turned a
+		// quarter clockwise, after which the other two flips swap axes.
+		rotation = 90
+		flipH, flipV = flipV, !flipH
+	}
+	if flipH {
+		src.Width = -src.Width
+	}
+	if flipV {
+		src.Height = -src.Height
+	}
+
+	// Turn about the tile's center so it stays in its cell
+	origin := rl.Vector2{X: dest.Width / 2, Y: dest.Height / 2}
+	dest.X += origin.X
+	dest.Y += origin.Y
+	rl.DrawTexturePro(tex, src, dest, origin, rotation, tint)
+}
+
+// registerMapCommands adds the console commands that edit the map.
+// Autotiled layers refit the tiles around each change. Solid tiles block the
+// player, so like other world edits these are refused while recording, and
+// in a generated world the chunks they change are kept with the save.
+func registerMapCommands() {
+	commands.Register(console.Command{
+		Name: "tile",
+		Args: "paint|erase <layer> <x> <y>",
+		Help: "paint a map cell with its layer's terrain, or clear it; x and y count tiles",
+		Run: func(args []string) (string, error) {
+			if len(args) != 4 || (args[0] != "paint" && args[0] != "erase") {
+				return "", console.ErrUsage
+			}
+			if recordingOrReplaying() {
+				return "", errors.New("the map can't be changed from the console while recording or replaying")
+			}
+			x, errX := strconv.Atoi(args[2])
+			y, errY := strconv.Atoi(args[3])
+			if errX != nil || errY != nil {
+				return "", fmt.Errorf("tile %s %s is not two whole numbers", args[2], args[3])
+			}
+
+			if args[0] == "erase" {
+				if err := world.EraseTile(args[1], x, y); err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("erased %s at %d, %d", args[1], x, y), nil
+			}
+			if err := world.PaintTile(args[1], x, y); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("painted %s at %d, %d", args[1], x, y), nil
+		},
+		Complete: func(args []string) []string {
+			switch {
+			case len(args) == 1:
+				return []string{"paint", "erase"}
+			case len(args) == 2 && worldMap != nil:
+				var names []string
+				for _, l := range worldMap.Layers {
+					names = append(names, l.Name)
+				}
+				return names
+			}
+			return nil
+		},
+	})
+}
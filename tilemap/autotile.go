@@ -0,0 +1,281 @@
+package tilemap
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// PropAutotile is the layer property naming the terrain whose tiles the
+// layer is drawn with. Those layers are autotiled: each terrain tile is
+// swapped for the one whose edges and corners match its neighbors, so maps
+// can be painted with any tile of the terrain and cells can be painted and
+// erased while the game runs.
+const PropAutotile = "autotile"
+
+// WangSet is a terrain as Tiled's terrain editor defines it: for each tile,
+// the terrain color found along each of its edges and at each of its corners.
+type WangSet struct {
+	Name  string
+	Type  string // "edge", "corner" or "mixed"
+	Tiles []WangTile
+}
+
+// WangTile gives one tile's colors, clockwise from the top edge: top,
+// top-right, right, bottom-right, bottom, bottom-left, left, top-left. 0 is
+// no terrain.
+type WangTile struct {
+	TileID int
+	WangID [8]int
+}
+
+// Neighbor directions, in WangID order, as bits of a neighbor mask.
+const (
+	maskN uint8 = 1 << iota
+	maskNE
+	maskE
+	maskSE
+	maskS
+	maskSW
+	maskW
+	maskNW
+
+	maskEdges = maskN | maskE | maskS | maskW
+)
+
+var neighborOffsets = [8][2]int{{0, -1}, {1, -1}, {1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}}
+
+// Terrain is a wang set ready for autotiling. Edge sets look at a cell's 4
+// neighbors and need 16 tiles for every case; mixed sets also look at the
+// diagonals, where a corner only counts if both edges beside it do, for the
+// 47 tiles of a full blob set. Cases a tileset has no tile for get the
+// closest tile it does have.
+type Terrain struct {
+	Name      string
+	Tileset   *Tileset
+	Neighbors int // 4 or 8
+
+	members map[int]bool
+	tiles   [256][]int // Candidate tiles for each neighbor mask
+}
+
+func newTerrain(ts *Tileset, ws *WangSet) (*Terrain, error) {
+	t := &Terrain{
+		Name:      ws.Name,
+		Tileset:   ts,
+		Neighbors: 8,
+		members:   make(map[int]bool),
+	}
+	switch ws.Type {
+	case "edge":
+		t.Neighbors = 4
+	case "mixed", "":
+	default:
+		return nil, fmt.Errorf("terrain %q: %s terrains aren't supported, only edge and mixed ones", ws.Name, ws.Type)
+	}
+
+	var exact [256][]int
+	for _, wt := range ws.Tiles {
+		var mask uint8
+		for i, color := range wt.WangID {
+			if color != 0 {
+				mask |= 1 << i
+			}
+		}
+		if mask == 0 {
+			continue
+		}
+		mask = t.reduce(mask)
+		exact[mask] = append(exact[mask], wt.TileID)
+		t.members[wt.TileID] = true
+	}
+	if len(t.members) == 0 {
+		return nil, fmt.Errorf("terrain %q has no tiles", ws.Name)
+	}
+
+	// Fill in the cases the tileset leaves out with the tiles that differ in
+	// the fewest neighbors.
+	for mask := range t.tiles {
+		best := 9
+		for other, tiles := range exact {
+			if len(tiles) == 0 {
+				continue
+			}
+			if d := bits.OnesCount8(uint8(mask ^ other)); d < best {
+				best = d
+				t.tiles[mask] = tiles
+			}
+		}
+	}
+	return t, nil
+}
+
+// reduce drops the neighbors that don't affect which tile fits: every
+// corner for edge terrains, and corners without both of their edges for
+// mixed ones.
+func (t *Terrain) reduce(mask uint8) uint8 {
+	edges := mask & maskEdges
+	if t.Neighbors == 4 {
+		return edges
+	}
+	corners := mask &^ maskEdges
+	for _, c := range [...]struct{ corner, a, b uint8 }{
+		{maskNE, maskN, maskE},
+		{maskSE, maskS, maskE},
+		{maskSW, maskS, maskW},
+		{maskNW, maskN, maskW},
+	} {
+		if mask&c.a == 0 || mask&c.b == 0 {
+			corners &^= c.corner
+		}
+	}
+	return edges | corners
+}
+
+// Has reports whether gid is a tile of the terrain.
+func (t *Terrain) Has(gid GID) bool {
+	id := gid.ID()
+	if id < t.Tileset.FirstGID {
+		return false
+	}
+	return t.members[int(id-t.Tileset.FirstGID)]
+}
+
+// tile picks the tile for a cell at x, y with the given neighbors. When
+// several fit, the choice is weighted by their probabilities but fixed for
+// each cell, so a cell keeps its look when its neighbors change.
+func (t *Terrain) tile(mask uint8, x, y int) GID {
+	tiles := t.tiles[mask]
+	if len(tiles) == 1 {
+		return t.Tileset.FirstGID + GID(tiles[0])
+	}
+
+	var total float64
+	for _, id := range tiles {
+		total += t.Tileset.Probability(id)
+	}
+	pick := cellHash(x, y) * total
+	for _, id := range tiles {
+		pick -= t.Tileset.Probability(id)
+		if pick < 0 {
+			return t.Tileset.FirstGID + GID(id)
+		}
+	}
+	return t.Tileset.FirstGID + GID(tiles[len(tiles)-1])
+}
+
+// cellHash returns a number in [0, 1) that looks random but only depends on
+// the cell.
+func cellHash(x, y int) float64 {
+	h := uint32(x)*0x9e3779b1 ^ uint32(y)*0x85ebca77
+	h ^= h >> 15
+	h *= 0x2c1b3c6d
+	h ^= h >> 12
+	return float64(h) / (1 << 32)
+}
+
+// Terrain returns the terrain of the given name from any of the map's
+// tilesets.
+func (m *Map) Terrain(name string) (*Terrain, error) {
+	for _, ts := range m.Tilesets {
+		for _, ws := range ts.WangSets {
+			if ws.Name == name {
+				return newTerrain(ts, ws)
+			}
+		}
+	}
+	return nil, fmt.Errorf("no terrain %q in any tileset", name)
+}
+
+// autotile sets up every layer with PropAutotile and fits all its tiles.
+func (m *Map) autotile() error {
+	for _, l := range m.Layers {
+		name := l.Properties.String(PropAutotile)
+		if name == "" {
+			continue
+		}
+		t, err := m.Terrain(name)
+		if err != nil {
+			return fmt.Errorf("layer %q: %w", l.Name, err)
+		}
+		l.Terrain = t
+
+		for c, chunk := range l.chunks {
+			for i := range chunk {
+				l.fit(c.X*ChunkSize+i%ChunkSize, c.Y*ChunkSize+i/ChunkSize)
+			}
+		}
+	}
+	return nil
+}
+
+// Paint makes the cell at x, y part of the layer's terrain and refits it
+// and its neighbors.
+func (l *Layer) Paint(x, y int) error {
+	if l.Terrain == nil {
+		return fmt.Errorf("layer %q isn't autotiled", l.Name)
+	}
+	l.Set(x, y, l.Terrain.tile(0, x, y))
+	l.refit(x, y)
+	return nil
+}
+
+// Erase clears the cell at x, y and refits its neighbors.
+func (l *Layer) Erase(x, y int) {
+	l.Set(x, y, 0)
+	if l.Terrain != nil {
+		l.refit(x, y)
+	}
+}
+
+// FillTerrain makes each cell of chunk c part of the layer's terrain where
+// has says so and clears the rest, then fits the chunk's tiles and those
+// bordering it.
+func (l *Layer) FillTerrain(c ChunkCoord, has func(x, y int) bool) error {
+	if l.Terrain == nil {
+		return fmt.Errorf("layer %q isn't autotiled", l.Name)
+	}
+	chunk := new(Chunk)
+	for i := range chunk {
+		x, y := c.X*ChunkSize+i%ChunkSize, c.Y*ChunkSize+i/ChunkSize
+		if has(x, y) {
+			chunk[i] = l.Terrain.tile(0, x, y)
+		}
+	}
+	l.SetChunk(c, chunk)
+	return nil
+}
+
+// fitChunk fits chunk c and the ring of cells around it.
+func (l *Layer) fitChunk(c ChunkCoord) {
+	x0, y0 := c.X*ChunkSize, c.Y*ChunkSize
+	for y := y0 - 1; y <= y0+ChunkSize; y++ {
+		for x := x0 - 1; x <= x0+ChunkSize; x++ {
+			l.fit(x, y)
+		}
+	}
+}
+
+// refit fits the cell at x, y and the eight around it, the only ones whose
+// tiles a change there can affect.
+func (l *Layer) refit(x, y int) {
+	l.fit(x, y)
+	for _, o := range neighborOffsets {
+		l.fit(x+o[0], y+o[1])
+	}
+}
+
+// fit replaces the terrain tile at x, y, if there is one, with the one that
+// matches its neighbors.
+func (l *Layer) fit(x, y int) {
+	t := l.Terrain
+	if !t.Has(l.At(x, y)) {
+		return
+	}
+	var mask uint8
+	for i, o := range neighborOffsets {
+		if t.Has(l.At(x+o[0], y+o[1])) {
+			mask |= 1 << i
+		}
+	}
+	l.Set(x, y, t.tile(t.reduce(mask), x, y))
+}
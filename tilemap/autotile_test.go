@@ -0,0 +1,261 @@
+package tilemap
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"main/res"
+)
+
+// maskTerrain returns a terrain whose tile for each neighbor mask in masks
+// has that mask as its ID, so a fitted cell's tile says which neighbors it
+// was fitted to.
+func maskTerrain(t *testing.T, typ string, masks []uint8) *Terrain {
+	t.Helper()
+	ws := &WangSet{Name: "test", Type: typ}
+	for _, mask := range masks {
+		wt := WangTile{TileID: int(mask)}
+		for i := range wt.WangID {
+			if mask&(1<<i) != 0 {
+				wt.WangID[i] = 1
+			}
+		}
+		ws.Tiles = append(ws.Tiles, wt)
+	}
+	terrain, err := newTerrain(&Tileset{FirstGID: 1}, ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return terrain
+}
+
+// allMasks returns every neighbor mask but 0 that the terrain type tells
+// apart, so there's one tile for each case.
+func allMasks(typ string) []uint8 {
+	reducer := &Terrain{Neighbors: 8}
+	if typ == "edge" {
+		reducer.Neighbors = 4
+	}
+	var masks []uint8
+	for mask := 1; mask < 256; mask++ {
+		if reducer.reduce(uint8(mask)) == uint8(mask) {
+			masks = append(masks, uint8(mask))
+		}
+	}
+	return masks
+}
+
+func TestReduce(t *testing.T) {
+	tests := []struct {
+		typ  string
+		mask uint8
+		want uint8
+	}{
+		{"edge", maskN | maskNE | maskE, maskN | maskE},
+		{"edge", maskNE | maskSE | maskSW | maskNW, 0},
+		{"edge", 0xff, maskEdges},
+		{"mixed", maskN | maskNE | maskE, maskN | maskNE | maskE},
+		{"mixed", maskN | maskNE, maskN},
+		{"mixed", maskNE | maskSE | maskSW | maskNW, 0},
+		{"mixed", maskS | maskSE | maskSW | maskW, maskS | maskSW | maskW},
+		{"mixed", 0xff, 0xff},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%08b", tt.typ, tt.mask), func(t *testing.T) {
+			terrain := maskTerrain(t, tt.typ, []uint8{maskN})
+			if got := terrain.reduce(tt.mask); got != tt.want {
+				t.Errorf("reduce = %08b, want %08b", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTerrainClosestTile(t *testing.T) {
+	// An edge terrain with only straight runs and a cross.
+	terrain := maskTerrain(t, "edge", []uint8{maskN | maskS, maskE | maskW, maskEdges})
+	tests := []struct {
+		mask uint8
+		want uint8
+	}{
+		{maskN | maskS, maskN | maskS},
+		{maskN, maskN | maskS},
+		{maskE, maskE | maskW},
+		{maskEdges, maskEdges},
+		{maskN | maskE | maskS, maskN | maskS}, // Ties go to the lowest mask
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%08b", tt.mask), func(t *testing.T) {
+			if got := terrain.tile(tt.mask, 0, 0); got != 1+GID(tt.want) {
+				t.Errorf("tile = %d, want %d", got, 1+GID(tt.want))
+			}
+		})
+	}
+}
+
+// parseCells returns the cells marked # in rows.
+func parseCells(rows string) [][2]int {
+	var cells [][2]int
+	for y, row := range strings.Split(strings.TrimSpace(rows), "\n") {
+		for x, c := range strings.TrimSpace(row) {
+			if c == '#' {
+				cells = append(cells, [2]int{x, y})
+			}
+		}
+	}
+	return cells
+}
+
+func TestPaintErase(t *testing.T) {
+	type check struct {
+		x, y int
+		want uint8
+	}
+	tests := []struct {
+		name   string
+		typ    string
+		paint  string
+		erase  [][2]int
+		checks []check
+	}{
+		{
+			name: "mixed block",
+			typ:  "mixed",
+			paint: `
+				###
+				###
+				###`,
+			checks: []check{
+				{0, 0, maskE | maskSE | maskS},
+				{1, 0, maskE | maskSE | maskS | maskSW | maskW},
+				{1, 1, 0xff},
+				{2, 2, maskN | maskW | maskNW},
+			},
+		},
+		{
+			name: "mixed corner without its edges",
+			typ:  "mixed",
+			paint: `
+				#.
+				.#`,
+			// There's no tile for a lone cell, so they get the first of the
+			// closest ones.
+			checks: []check{
+				{0, 0, maskN},
+				{1, 1, maskN},
+			},
+		},
+		{
+			name: "mixed erase",
+			typ:  "mixed",
+			paint: `
+				###
+				###
+				###`,
+			erase: [][2]int{{1, 1}},
+			checks: []check{
+				{1, 0, maskE | maskW},
+				{0, 1, maskN | maskS},
+				{0, 0, maskE | maskS},
+			},
+		},
+		{
+			name: "edge block",
+			typ:  "edge",
+			paint: `
+				###
+				###
+				###`,
+			checks: []check{
+				{0, 0, maskE | maskS},
+				{1, 0, maskE | maskS | maskW},
+				{1, 1, maskEdges},
+			},
+		},
+		{
+			name:  "across chunks",
+			typ:   "edge",
+			paint: strings.Repeat(".", ChunkSize-1) + "##",
+			checks: []check{
+				{ChunkSize - 1, 0, maskE},
+				{ChunkSize, 0, maskW},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newLayer("test", true, 1, nil)
+			l.Terrain = maskTerrain(t, tt.typ, allMasks(tt.typ))
+			for _, c := range parseCells(tt.paint) {
+				if err := l.Paint(c[0], c[1]); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, c := range tt.erase {
+				l.Erase(c[0], c[1])
+			}
+			for _, c := range tt.checks {
+				if got := l.At(c.x, c.y); got != 1+GID(c.want) {
+					t.Errorf("cell %d,%d fitted to %08b, want %08b", c.x, c.y, got-1, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPaintNotAutotiled(t *testing.T) {
+	l := newLayer("plain", true, 1, nil)
+	if err := l.Paint(0, 0); err == nil {
+		t.Error("painted a layer that isn't autotiled")
+	}
+}
+
+// TestShippedTerrains checks the terrains in res have a tile for every
+// case they can be asked for.
+func TestShippedTerrains(t *testing.T) {
+	tests := []struct {
+		terrain string
+		want    int // Neighbor masks, other than none
+	}{
+		{"Grass", 46},
+		{"Hills", 15},
+	}
+	m, err := Load(res.FS, "maps/world.tmj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.terrain, func(t *testing.T) {
+			terrain, err := m.Terrain(tt.terrain)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cases := make(map[uint8]bool)
+			for mask := range 256 {
+				if r := terrain.reduce(uint8(mask)); r != 0 {
+					cases[r] = true
+				}
+			}
+			if len(cases) != tt.want {
+				t.Fatalf("%d cases, want %d", len(cases), tt.want)
+			}
+			for _, ws := range terrain.Tileset.WangSets {
+				if ws.Name != tt.terrain {
+					continue
+				}
+				for _, wt := range ws.Tiles {
+					var mask uint8
+					for i, color := range wt.WangID {
+						if color != 0 {
+							mask |= 1 << i
+						}
+					}
+					delete(cases, terrain.reduce(mask))
+				}
+			}
+			if len(cases) > 0 {
+				t.Errorf("no tile for %d cases", len(cases))
+			}
+		})
+	}
+}
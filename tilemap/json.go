@@ -0,0 +1,290 @@
+package tilemap
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"time"
+)
+
+// Tiled's JSON formats: .tmj for maps and .tsj for tilesets.
+
+type jsonMap struct {
+	Orientation string             `json:"orientation"`
+	Width       int                `json:"width"`
+	Height      int                `json:"height"`
+	TileWidth   int                `json:"tilewidth"`
+	TileHeight  int                `json:"tileheight"`
+	Infinite    bool               `json:"infinite"`
+	Properties  []jsonProperty     `json:"properties"`
+	Tilesets    []jsonTilesetEntry `json:"tilesets"`
+	Layers      []jsonLayer        `json:"layers"`
+}
+
+// jsonTilesetEntry is a tileset as listed in a map: either written out in
+// full or a Source pointing at a tileset file.
+type jsonTilesetEntry struct {
+	FirstGID GID    `json:"firstgid"`
+	Source   string `json:"source"`
+	jsonTileset
+}
+
+type jsonTileset struct {
+	Name        string         `json:"name"`
+	TileWidth   int            `json:"tilewidth"`
+	TileHeight  int            `json:"tileheight"`
+	Columns     int            `json:"columns"`
+	TileCount   int            `json:"tilecount"`
+	Spacing     int            `json:"spacing"`
+	Margin      int            `json:"margin"`
+	Image       string         `json:"image"`
+	ImageWidth  int            `json:"imagewidth"`
+	ImageHeight int            `json:"imageheight"`
+	Properties  []jsonProperty `json:"properties"`
+	Tiles       []jsonTile     `json:"tiles"`
+	WangSets    []struct {
+		Name      string `json:"name"`
+		Type      string `json:"type"`
+		WangTiles []struct {
+			TileID int    `json:"tileid"`
+			WangID [8]int `json:"wangid"`
+		} `json:"wangtiles"`
+	} `json:"wangsets"`
+}
+
+type jsonTile struct {
+	ID          int            `json:"id"`
+	Probability *float64       `json:"probability"`
+	Properties  []jsonProperty `json:"properties"`
+	Animation   []struct {
+		TileID   int `json:"tileid"`
+		Duration int `json:"duration"` // Milliseconds
+	} `json:"animation"`
+}
+
+type jsonLayer struct {
+	Type        string          `json:"type"`
+	Name        string          `json:"name"`
+	Visible     bool            `json:"visible"`
+	Opacity     float32         `json:"opacity"`
+	X           int             `json:"x"`
+	Y           int             `json:"y"`
+	Width       int             `json:"width"`
+	Height      int             `json:"height"`
+	Encoding    string          `json:"encoding"`
+	Compression string          `json:"compression"`
+	Data        json.RawMessage `json:"data"`
+	Chunks      []jsonChunk     `json:"chunks"`
+	Layers      []jsonLayer     `json:"layers"` // Of a group
+	Properties  []jsonProperty  `json:"properties"`
+}
+
+type jsonChunk struct {
+	X      int             `json:"x"`
+	Y      int             `json:"y"`
+	Width  int             `json:"width"`
+	Height int             `json:"height"`
+	Data   json.RawMessage `json:"data"`
+}
+
+type jsonProperty struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value any    `json:"value"`
+}
+
+func parseJSONMap(fsys fs.FS, name string, data []byte) (*Map, error) {
+	var jm jsonMap
+	if err := json.Unmarshal(data, &jm); err != nil {
+		return nil, err
+	}
+	if jm.Orientation != "orthogonal" {
+		return nil, fmt.Errorf("%q maps aren't supported, only orthogonal ones", jm.Orientation)
+	}
+
+	props, err := jsonProperties(jm.Properties)
+	if err != nil {
+		return nil, err
+	}
+	m := &Map{
+		Width:      jm.Width,
+		Height:     jm.Height,
+		TileWidth:  jm.TileWidth,
+		TileHeight: jm.TileHeight,
+		Infinite:   jm.Infinite,
+		Properties: props,
+	}
+
+	for _, entry := range jm.Tilesets {
+		var ts *Tileset
+		if entry.Source != "" {
+			source, err := resolve(name, entry.Source)
+			if err != nil {
+				return nil, err
+			}
+			if ts, err = loadTileset(fsys, source); err != nil {
+				return nil, err
+			}
+		} else if ts, err = entry.jsonTileset.tileset(name); err != nil {
+			return nil, fmt.Errorf("tileset %q: %w", entry.Name, err)
+		}
+		ts.FirstGID = entry.FirstGID
+		m.Tilesets = append(m.Tilesets, ts)
+	}
+
+	if err := m.addJSONLayers(jm.Layers, true, 1); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// addJSONLayers adds the tile layers in layers to the map, flattening
+// groups into their visibility and opacity.
+func (m *Map) addJSONLayers(layers []jsonLayer, visible bool, opacity float32) error {
+	for _, jl := range layers {
+		switch jl.Type {
+		case "group":
+			if err := m.addJSONLayers(jl.Layers, visible && jl.Visible, opacity*jl.Opacity); err != nil {
+				return err
+			}
+		case "tilelayer":
+			props, err := jsonProperties(jl.Properties)
+			if err != nil {
+				return fmt.Errorf("layer %q: %w", jl.Name, err)
+			}
+			l := newLayer(jl.Name, visible && jl.Visible, opacity*jl.Opacity, props)
+			if err := l.fillJSON(jl); err != nil {
+				return err
+			}
+			m.Layers = append(m.Layers, l)
+		}
+	}
+	return nil
+}
+
+func (l *Layer) fillJSON(jl jsonLayer) error {
+	// Layers of infinite maps always list their chunks, if only as [].
+	if jl.Chunks == nil {
+		gids, err := jsonTiles(jl.Encoding, jl.Compression, jl.Data)
+		if err != nil {
+			return fmt.Errorf("layer %q: %w", l.Name, err)
+		}
+		return l.fill(jl.X, jl.Y, jl.Width, jl.Height, gids)
+	}
+	for _, c := range jl.Chunks {
+		gids, err := jsonTiles(jl.Encoding, jl.Compression, c.Data)
+		if err != nil {
+			return fmt.Errorf("layer %q: %w", l.Name, err)
+		}
+		if err := l.fill(c.X, c.Y, c.Width, c.Height, gids); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonTiles decodes layer data, which is an array of GIDs unless the layer
+// is base64 encoded.
+func jsonTiles(encoding, compression string, data json.RawMessage) ([]GID, error) {
+	if encoding == "base64" {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, err
+		}
+		return decodeTiles(encoding, compression, s)
+	}
+	var gids []GID
+	if err := json.Unmarshal(data, &gids); err != nil {
+		return nil, err
+	}
+	return gids, nil
+}
+
+func parseJSONTileset(name string, data []byte) (*Tileset, error) {
+	var jt jsonTileset
+	if err := json.Unmarshal(data, &jt); err != nil {
+		return nil, err
+	}
+	return jt.tileset(name)
+}
+
+// tileset converts a tileset read from the file at name.
+func (jt *jsonTileset) tileset(name string) (*Tileset, error) {
+	ts := &Tileset{
+		Name:        jt.Name,
+		TileWidth:   jt.TileWidth,
+		TileHeight:  jt.TileHeight,
+		Columns:     jt.Columns,
+		TileCount:   jt.TileCount,
+		Spacing:     jt.Spacing,
+		Margin:      jt.Margin,
+		ImageWidth:  jt.ImageWidth,
+		ImageHeight: jt.ImageHeight,
+		Tiles:       make(map[int]*TileInfo),
+	}
+
+	var err error
+	if jt.Image != "" {
+		if ts.Image, err = resolve(name, jt.Image); err != nil {
+			return nil, err
+		}
+	}
+	if ts.Properties, err = jsonProperties(jt.Properties); err != nil {
+		return nil, err
+	}
+
+	for _, t := range jt.Tiles {
+		props, err := jsonProperties(t.Properties)
+		if err != nil {
+			return nil, fmt.Errorf("tile %d: %w", t.ID, err)
+		}
+		info := &TileInfo{Properties: props, Probability: 1}
+		if t.Probability != nil {
+			info.Probability = *t.Probability
+		}
+		for _, f := range t.Animation {
+			info.Animation = append(info.Animation, AnimationFrame{
+				TileID:   f.TileID,
+				Duration: time.Duration(f.Duration) * time.Millisecond,
+			})
+		}
+		ts.Tiles[t.ID] = info
+	}
+
+	for _, jw := range jt.WangSets {
+		ws := &WangSet{Name: jw.Name, Type: jw.Type}
+		for _, wt := range jw.WangTiles {
+			ws.Tiles = append(ws.Tiles, WangTile{TileID: wt.TileID, WangID: wt.WangID})
+		}
+		ts.WangSets = append(ts.WangSets, ws)
+	}
+	return ts, nil
+}
+
+func jsonProperties(list []jsonProperty) (Properties, error) {
+	if len(list) == 0 {
+		return nil, nil
+	}
+	props := make(Properties, len(list))
+	for _, p := range list {
+		switch v := p.Value.(type) {
+		case bool:
+			props[p.Name] = v
+		case float64:
+			if p.Type == "int" {
+				props[p.Name] = int(v)
+			} else {
+				props[p.Name] = v
+			}
+		case string:
+			value, err := property(p.Type, v)
+			if err != nil {
+				return nil, fmt.Errorf("property %q: %w", p.Name, err)
+			}
+			props[p.Name] = value
+		default:
+			props[p.Name] = fmt.Sprint(v)
+		}
+	}
+	return props, nil
+}
@@ -0,0 +1,245 @@
+package tilemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ChunkSize is the width and height of a layer chunk, in tiles. Layers are
+// stored and drawn a chunk at a time, so only chunks in view cost anything.
+const ChunkSize = 16
+
+// ChunkCoord is a chunk's position, counted in chunks.
+type ChunkCoord struct {
+	X, Y int
+}
+
+// ChunkOf returns the chunk holding tile x, y.
+func ChunkOf(x, y int) ChunkCoord {
+	return ChunkCoord{floorDiv(x, ChunkSize), floorDiv(y, ChunkSize)}
+}
+
+// Chunk is a square of tiles in a layer, row by row.
+type Chunk [ChunkSize * ChunkSize]GID
+
+// Layer is one tile layer of a map.
+type Layer struct {
+	Name       string
+	Visible    bool
+	Opacity    float32
+	Properties Properties
+	Terrain    *Terrain // What the layer is autotiled with, if it is
+
+	chunks map[ChunkCoord]*Chunk
+}
+
+func newLayer(name string, visible bool, opacity float32, props Properties) *Layer {
+	return &Layer{
+		Name:       name,
+		Visible:    visible,
+		Opacity:    opacity,
+		Properties: props,
+		chunks:     make(map[ChunkCoord]*Chunk),
+	}
+}
+
+// At returns the tile at x, y, or 0 if there is none.
+func (l *Layer) At(x, y int) GID {
+	c := l.chunks[ChunkOf(x, y)]
+	if c == nil {
+		return 0
+	}
+	return c[chunkIndex(x, y)]
+}
+
+// Set puts a tile at x, y. Setting 0 clears it.
+func (l *Layer) Set(x, y int, gid GID) {
+	coord := ChunkOf(x, y)
+	c := l.chunks[coord]
+	if c == nil {
+		if gid == 0 {
+			return
+		}
+		c = new(Chunk)
+		l.chunks[coord] = c
+	}
+	c[chunkIndex(x, y)] = gid
+}
+
+// Chunk returns the chunk at c, or nil if it has no tiles.
+func (l *Layer) Chunk(c ChunkCoord) *Chunk {
+	return l.chunks[c]
+}
+
+// SetChunk replaces the tiles of chunk c; nil clears them. On an autotiled
+// layer the tiles along the chunk's edges, inside it and out, are refit to
+// their new neighbors.
+func (l *Layer) SetChunk(c ChunkCoord, chunk *Chunk) {
+	if chunk == nil {
+		delete(l.chunks, c)
+	} else {
+		l.chunks[c] = chunk
+	}
+	if l.Terrain != nil {
+		l.fitChunk(c)
+	}
+}
+
+// RestoreChunk replaces the tiles of chunk c like SetChunk, but leaves every
+// tile as given, so a chunk copied from the layer comes back exactly.
+func (l *Layer) RestoreChunk(c ChunkCoord, chunk *Chunk) {
+	if chunk == nil {
+		delete(l.chunks, c)
+	} else {
+		l.chunks[c] = chunk
+	}
+}
+
+// Clear removes every tile from the layer.
+func (l *Layer) Clear() {
+	clear(l.chunks)
+}
+
+// fill sets a width by height block of tiles with its top-left corner at x,
+// y, as Tiled lays out layer and chunk data.
+func (l *Layer) fill(x, y, width, height int, gids []GID) error {
+	if len(gids) != width*height {
+		return fmt.Errorf("layer %q: %d tiles of data for a %dx%d area", l.Name, len(gids), width, height)
+	}
+	for i, gid := range gids {
+		if gid != 0 {
+			l.Set(x+i%width, y+i/width, gid)
+		}
+	}
+	return nil
+}
+
+func chunkIndex(x, y int) int {
+	return floorMod(y, ChunkSize)*ChunkSize + floorMod(x, ChunkSize)
+}
+
+// floorDiv and floorMod round toward negative infinity, so tiles left of or
+// above the origin land in the chunks there.
+func floorDiv(a, b int) int {
+	return (a - floorMod(a, b)) / b
+}
+
+func floorMod(a, b int) int {
+	return (a%b + b) % b
+}
+
+// decodeTiles decodes layer data stored as CSV or as base64, optionally
+// compressed with gzip or zlib.
+func decodeTiles(encoding, compression, data string) ([]GID, error) {
+	switch encoding {
+	case "csv":
+		var gids []GID
+		for _, field := range strings.Split(data, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			n, err := strconv.ParseUint(field, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("bad tile %q in CSV data", field)
+			}
+			gids = append(gids, GID(n))
+		}
+		return gids, nil
+
+	case "base64":
+		raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(data))
+		if err != nil {
+			return nil, fmt.Errorf("decoding base64 data: %w", err)
+		}
+		raw, err = decompress(compression, raw)
+		if err != nil {
+			return nil, err
+		}
+		if len(raw)%4 != 0 {
+			return nil, fmt.Errorf("tile data is %d bytes, which is not a whole number of tiles", len(raw))
+		}
+		gids := make([]GID, len(raw)/4)
+		for i := range gids {
+			gids[i] = GID(binary.LittleEndian.Uint32(raw[i*4:]))
+		}
+		return gids, nil
+	}
+	return nil, fmt.Errorf("unsupported tile data encoding %q", encoding)
+}
+
+func decompress(compression string, raw []byte) ([]byte, error) {
+	var r io.ReadCloser
+	var err error
+	switch compression {
+	case "":
+		return raw, nil
+	case "gzip":
+		r, err = gzip.NewReader(bytes.NewReader(raw))
+	case "zlib":
+		r, err = zlib.NewReader(bytes.NewReader(raw))
+	default:
+		return nil, fmt.Errorf("unsupported tile data compression %q", compression)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decompressing tile data: %w", err)
+	}
+	defer r.Close()
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing tile data: %w", err)
+	}
+	return out, nil
+}
+
+// Properties are the custom properties set on a map, layer, tileset or tile.
+// Values are bool, int, float64 or string, following the type set in Tiled;
+// colors, files and the rest come through as strings.
+type Properties map[string]any
+
+// Bool returns a boolean property, or false if it isn't set.
+func (p Properties) Bool(name string) bool {
+	v, _ := p[name].(bool)
+	return v
+}
+
+// Float returns a numeric property, or def if it isn't set.
+func (p Properties) Float(name string, def float64) float64 {
+	switch v := p[name].(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	}
+	return def
+}
+
+// String returns a property as text, or "" if it isn't set.
+func (p Properties) String(name string) string {
+	v, ok := p[name]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// property converts a property value written as text to its type.
+func property(typ, value string) (any, error) {
+	switch typ {
+	case "bool":
+		return strconv.ParseBool(value)
+	case "int":
+		return strconv.Atoi(value)
+	case "float":
+		return strconv.ParseFloat(value, 64)
+	}
+	return value, nil
+}
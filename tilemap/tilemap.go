@@ -0,0 +1,337 @@
+// Package tilemap loads multi-layer tile maps made with the Tiled editor
+// (https://www.mapeditor.org), from either its XML (.tmx, .tsx) or its JSON
+// (.tmj, .tsj) formats. It has no drawing code, so maps can be queried
+// headless; the game draws them from the tiles and tileset images it
+// describes.
+package tilemap
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PropScale is the map property saying how many world pixels each pixel of
+// the map covers, so small tiles can be drawn at the size of the rest of the
+// art.
+const PropScale = "scale"
+
+// PropGenerate is the map property that makes a map the template of a
+// generated world: its tilesets and layers are used as they are, and its
+// layers are filled in from the world's seed as the player explores.
+const PropGenerate = "generate"
+
+// Tile properties the game gives meaning to. They're set per tile in Tiled's
+// tileset editor.
+const (
+	PropSolid    = "solid"    // Blocks movement
+	PropWater    = "water"    // Open water
+	PropTillable = "tillable" // Can be dug into farmland
+	PropBiome    = "biome"    // Names the biome a generated world scatters the tile in
+)
+
+// GID is a global tile ID as Tiled stores it: 0 for no tile, otherwise the
+// tile's index counted across all of the map's tilesets, with the top bits
+// saying how the tile is flipped.
+type GID uint32
+
+const (
+	FlipH GID = 0x80000000 // Mirrored left to right
+	FlipV GID = 0x40000000 // Mirrored top to bottom
+	FlipD GID = 0x20000000 // Mirrored across the top-left to bottom-right diagonal
+
+	flipHex   GID = 0x10000000 // Only used by hexagonal maps, ignored
+	flipFlags     = FlipH | FlipV | FlipD | flipHex
+)
+
+// ID returns the tile's ID without the flip bits.
+func (g GID) ID() GID {
+	return g &^ flipFlags
+}
+
+// Map is a loaded Tiled map. Tile coordinates count from the map's top-left
+// tile; infinite maps can also have tiles at negative coordinates.
+type Map struct {
+	Width, Height         int // In tiles, or 0 for infinite maps
+	TileWidth, TileHeight int // In pixels
+	Infinite              bool
+	Properties            Properties
+
+	Tilesets []*Tileset // Ordered by FirstGID
+	Layers   []*Layer   // Tile layers from bottom to top, with groups flattened
+}
+
+// Scale returns the map's PropScale property, or 1 if it has none.
+func (m *Map) Scale() float32 {
+	return float32(m.Properties.Float(PropScale, 1))
+}
+
+// TileSize returns the size of a tile in world pixels.
+func (m *Map) TileSize() (width, height float32) {
+	scale := m.Scale()
+	return float32(m.TileWidth) * scale, float32(m.TileHeight) * scale
+}
+
+// Layer returns the tile layer with the given name, or nil.
+func (m *Map) Layer(name string) *Layer {
+	for _, l := range m.Layers {
+		if l.Name == name {
+			return l
+		}
+	}
+	return nil
+}
+
+// Tileset returns the tileset a tile comes from and the tile's ID within it.
+func (m *Map) Tileset(gid GID) (*Tileset, int, bool) {
+	id := gid.ID()
+	if id == 0 {
+		return nil, 0, false
+	}
+	i := sort.Search(len(m.Tilesets), func(i int) bool { return m.Tilesets[i].FirstGID > id }) - 1
+	if i < 0 {
+		return nil, 0, false
+	}
+	ts := m.Tilesets[i]
+	local := int(id - ts.FirstGID)
+	if local >= ts.TileCount {
+		return nil, 0, false
+	}
+	return ts, local, true
+}
+
+// TileProperties returns the properties of a tile, or nil if it has none.
+func (m *Map) TileProperties(gid GID) Properties {
+	ts, id, ok := m.Tileset(gid)
+	if !ok {
+		return nil
+	}
+	if info := ts.Tiles[id]; info != nil {
+		return info.Properties
+	}
+	return nil
+}
+
+// Flag reports whether the tile at x, y has the named boolean property set,
+// for example whether it's PropSolid. The topmost layer with a tile there
+// decides, so grass laid over water isn't water.
+func (m *Map) Flag(x, y int, name string) bool {
+	for i := len(m.Layers) - 1; i >= 0; i-- {
+		if ts, id, ok := m.Tileset(m.Layers[i].At(x, y)); ok {
+			return ts.Flag(id, name)
+		}
+	}
+	return false
+}
+
+// Bounds returns the range of tiles the map covers, as the top-left tile and
+// the one past the bottom-right. For infinite maps that's every chunk with
+// tiles in it.
+func (m *Map) Bounds() (minX, minY, maxX, maxY int) {
+	if !m.Infinite {
+		return 0, 0, m.Width, m.Height
+	}
+	first := true
+	for _, l := range m.Layers {
+		for c := range l.chunks {
+			x0, y0 := c.X*ChunkSize, c.Y*ChunkSize
+			if first {
+				minX, minY, maxX, maxY = x0, y0, x0+ChunkSize, y0+ChunkSize
+				first = false
+				continue
+			}
+			minX, minY = min(minX, x0), min(minY, y0)
+			maxX, maxY = max(maxX, x0+ChunkSize), max(maxY, y0+ChunkSize)
+		}
+	}
+	return minX, minY, maxX, maxY
+}
+
+// Tileset is a sprite sheet of tiles, with properties and animations for
+// individual tiles.
+type Tileset struct {
+	FirstGID GID // GID of the tileset's first tile in the map
+	Name     string
+
+	TileWidth, TileHeight int
+	Columns, TileCount    int
+	Spacing, Margin       int // Pixels between tiles and around the sheet's edge
+
+	Image                   string // Path of the sheet in the map's filesystem
+	ImageWidth, ImageHeight int
+
+	Properties Properties
+	Tiles      map[int]*TileInfo // By tile ID, for tiles with anything set
+	WangSets   []*WangSet        // Terrains for autotiling
+}
+
+// TileInfo is what a tileset says about one of its tiles.
+type TileInfo struct {
+	Properties  Properties
+	Animation   []AnimationFrame
+	Probability float64 // How often autotiling picks the tile over others that fit as well
+}
+
+// AnimationFrame shows another tile of the same tileset for a while.
+type AnimationFrame struct {
+	TileID   int
+	Duration time.Duration
+}
+
+// Flag reports whether tile id has the named boolean property set. A
+// property set on the tile wins over the same property set on the whole
+// tileset.
+func (ts *Tileset) Flag(id int, name string) bool {
+	if info := ts.Tiles[id]; info != nil {
+		if v, ok := info.Properties[name].(bool); ok {
+			return v
+		}
+	}
+	return ts.Properties.Bool(name)
+}
+
+// Probability returns how likely autotiling is to pick tile id, relative to
+// other tiles that fit the same place. Tiles are 1 unless set otherwise.
+func (ts *Tileset) Probability(id int) float64 {
+	if info := ts.Tiles[id]; info != nil {
+		return info.Probability
+	}
+	return 1
+}
+
+// Source returns the rectangle tile id occupies in the tileset image.
+func (ts *Tileset) Source(id int) (x, y, width, height int) {
+	col, row := id%ts.Columns, id/ts.Columns
+	x = ts.Margin + col*(ts.TileWidth+ts.Spacing)
+	y = ts.Margin + row*(ts.TileHeight+ts.Spacing)
+	return x, y, ts.TileWidth, ts.TileHeight
+}
+
+// Frame returns which tile an animated tile shows at time t, or id itself if
+// it isn't animated.
+func (ts *Tileset) Frame(id int, t time.Duration) int {
+	info := ts.Tiles[id]
+	if info == nil || len(info.Animation) == 0 {
+		return id
+	}
+	var total time.Duration
+	for _, f := range info.Animation {
+		total += f.Duration
+	}
+	if total <= 0 {
+		return id
+	}
+	t %= total
+	for _, f := range info.Animation {
+		if t < f.Duration {
+			return f.TileID
+		}
+		t -= f.Duration
+	}
+	return id
+}
+
+// Load reads the map at name in fsys, along with any external tilesets it
+// refers to, and fits the tiles of autotiled layers. The format is picked by
+// extension: .tmx for XML, .tmj or .json for JSON.
+func Load(fsys fs.FS, name string) (*Map, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("reading map: %w", err)
+	}
+
+	var m *Map
+	switch ext := strings.ToLower(path.Ext(name)); ext {
+	case ".tmx":
+		m, err = parseTMX(fsys, name, data)
+	case ".tmj", ".json":
+		m, err = parseJSONMap(fsys, name, data)
+	default:
+		return nil, fmt.Errorf("map %s: unknown map format %q", name, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("map %s: %w", name, err)
+	}
+
+	if err := m.check(); err != nil {
+		return nil, fmt.Errorf("map %s: %w", name, err)
+	}
+	if err := m.autotile(); err != nil {
+		return nil, fmt.Errorf("map %s: %w", name, err)
+	}
+	return m, nil
+}
+
+// loadTileset reads the external tileset at name in fsys.
+func loadTileset(fsys fs.FS, name string) (*Tileset, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var ts *Tileset
+	switch ext := strings.ToLower(path.Ext(name)); ext {
+	case ".tsx":
+		ts, err = parseTSX(name, data)
+	case ".tsj", ".json":
+		ts, err = parseJSONTileset(name, data)
+	default:
+		return nil, fmt.Errorf("tileset %s: unknown tileset format %q", name, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tileset %s: %w", name, err)
+	}
+	return ts, nil
+}
+
+// resolve turns a path written in the file at from into a path in the same
+// filesystem.
+func resolve(from, rel string) (string, error) {
+	p := path.Join(path.Dir(from), rel)
+	if !fs.ValidPath(p) {
+		return "", fmt.Errorf("%q is outside the resources", rel)
+	}
+	return p, nil
+}
+
+// check fills in what the map files may leave out and makes sure every tile
+// in every layer comes from one of the tilesets.
+func (m *Map) check() error {
+	if m.TileWidth <= 0 || m.TileHeight <= 0 {
+		return fmt.Errorf("tile size %dx%d is invalid", m.TileWidth, m.TileHeight)
+	}
+
+	sort.Slice(m.Tilesets, func(i, j int) bool { return m.Tilesets[i].FirstGID < m.Tilesets[j].FirstGID })
+	for _, ts := range m.Tilesets {
+		if ts.Image == "" {
+			return fmt.Errorf("tileset %q: image collection tilesets aren't supported", ts.Name)
+		}
+		if ts.TileWidth <= 0 || ts.TileHeight <= 0 {
+			return fmt.Errorf("tileset %q: tile size %dx%d is invalid", ts.Name, ts.TileWidth, ts.TileHeight)
+		}
+		if ts.Columns <= 0 {
+			ts.Columns = max((ts.ImageWidth-2*ts.Margin+ts.Spacing)/(ts.TileWidth+ts.Spacing), 1)
+		}
+		if ts.Tiles == nil {
+			ts.Tiles = make(map[int]*TileInfo)
+		}
+	}
+
+	for _, l := range m.Layers {
+		for c, chunk := range l.chunks {
+			for i, gid := range chunk {
+				if gid == 0 {
+					continue
+				}
+				if _, _, ok := m.Tileset(gid); !ok {
+					x, y := c.X*ChunkSize+i%ChunkSize, c.Y*ChunkSize+i/ChunkSize
+					return fmt.Errorf("layer %q: tile %d at %d,%d is in no tileset", l.Name, gid.ID(), x, y)
+				}
+			}
+		}
+	}
+	return nil
+}
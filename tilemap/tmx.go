@@ -0,0 +1,307 @@
+package tilemap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tiled's XML formats: .tmx for maps and .tsx for tilesets.
+
+type tmxMap struct {
+	Orientation string        `xml:"orientation,attr"`
+	Width       int           `xml:"width,attr"`
+	Height      int           `xml:"height,attr"`
+	TileWidth   int           `xml:"tilewidth,attr"`
+	TileHeight  int           `xml:"tileheight,attr"`
+	Infinite    int           `xml:"infinite,attr"`
+	Properties  []tmxProperty `xml:"properties>property"`
+	Tilesets    []tmxTileset  `xml:"tileset"`
+	Layers      []tmxLayer    `xml:",any"` // Tile, object and image layers and groups, in order
+}
+
+type tmxTileset struct {
+	FirstGID   GID           `xml:"firstgid,attr"`
+	Source     string        `xml:"source,attr"`
+	Name       string        `xml:"name,attr"`
+	TileWidth  int           `xml:"tilewidth,attr"`
+	TileHeight int           `xml:"tileheight,attr"`
+	Columns    int           `xml:"columns,attr"`
+	TileCount  int           `xml:"tilecount,attr"`
+	Spacing    int           `xml:"spacing,attr"`
+	Margin     int           `xml:"margin,attr"`
+	Image      tmxImage      `xml:"image"`
+	Properties []tmxProperty `xml:"properties>property"`
+	Tiles      []tmxTile     `xml:"tile"`
+	WangSets   []struct {
+		Name      string `xml:"name,attr"`
+		Type      string `xml:"type,attr"`
+		WangTiles []struct {
+			TileID int    `xml:"tileid,attr"`
+			WangID string `xml:"wangid,attr"` // Eight comma-separated colors
+		} `xml:"wangtile"`
+	} `xml:"wangsets>wangset"`
+}
+
+type tmxImage struct {
+	Source string `xml:"source,attr"`
+	Width  int    `xml:"width,attr"`
+	Height int    `xml:"height,attr"`
+}
+
+type tmxTile struct {
+	ID          int           `xml:"id,attr"`
+	Probability *float64      `xml:"probability,attr"`
+	Properties  []tmxProperty `xml:"properties>property"`
+	Animation   []struct {
+		TileID   int `xml:"tileid,attr"`
+		Duration int `xml:"duration,attr"` // Milliseconds
+	} `xml:"animation>frame"`
+}
+
+type tmxLayer struct {
+	XMLName    xml.Name
+	Name       string        `xml:"name,attr"`
+	Visible    string        `xml:"visible,attr"` // "0" when hidden, usually left out otherwise
+	Opacity    string        `xml:"opacity,attr"`
+	X          int           `xml:"x,attr"`
+	Y          int           `xml:"y,attr"`
+	Width      int           `xml:"width,attr"`
+	Height     int           `xml:"height,attr"`
+	Data       tmxData       `xml:"data"`
+	Properties []tmxProperty `xml:"properties>property"`
+	Layers     []tmxLayer    `xml:",any"` // Of a group
+}
+
+type tmxData struct {
+	Encoding    string     `xml:"encoding,attr"`
+	Compression string     `xml:"compression,attr"`
+	Text        string     `xml:",chardata"`
+	Tiles       []tmxCell  `xml:"tile"` // When there's no encoding
+	Chunks      []tmxChunk `xml:"chunk"`
+}
+
+type tmxChunk struct {
+	X      int       `xml:"x,attr"`
+	Y      int       `xml:"y,attr"`
+	Width  int       `xml:"width,attr"`
+	Height int       `xml:"height,attr"`
+	Text   string    `xml:",chardata"`
+	Tiles  []tmxCell `xml:"tile"`
+}
+
+type tmxCell struct {
+	GID GID `xml:"gid,attr"`
+}
+
+type tmxProperty struct {
+	Name  string `xml:"name,attr"`
+	Type  string `xml:"type,attr"`
+	Value string `xml:"value,attr"`
+	Text  string `xml:",chardata"` // Multi-line strings are written here instead of Value
+}
+
+func parseTMX(fsys fs.FS, name string, data []byte) (*Map, error) {
+	var tm tmxMap
+	if err := xml.Unmarshal(data, &tm); err != nil {
+		return nil, err
+	}
+	if tm.Orientation != "orthogonal" {
+		return nil, fmt.Errorf("%q maps aren't supported, only orthogonal ones", tm.Orientation)
+	}
+
+	props, err := tmxProperties(tm.Properties)
+	if err != nil {
+		return nil, err
+	}
+	m := &Map{
+		Width:      tm.Width,
+		Height:     tm.Height,
+		TileWidth:  tm.TileWidth,
+		TileHeight: tm.TileHeight,
+		Infinite:   tm.Infinite != 0,
+		Properties: props,
+	}
+
+	for _, entry := range tm.Tilesets {
+		var ts *Tileset
+		if entry.Source != "" {
+			source, err := resolve(name, entry.Source)
+			if err != nil {
+				return nil, err
+			}
+			if ts, err = loadTileset(fsys, source); err != nil {
+				return nil, err
+			}
+		} else if ts, err = entry.tileset(name); err != nil {
+			return nil, fmt.Errorf("tileset %q: %w", entry.Name, err)
+		}
+		ts.FirstGID = entry.FirstGID
+		m.Tilesets = append(m.Tilesets, ts)
+	}
+
+	if err := m.addTMXLayers(tm.Layers, true, 1); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// addTMXLayers adds the tile layers in layers to the map, flattening groups
+// into their visibility and opacity.
+func (m *Map) addTMXLayers(layers []tmxLayer, visible bool, opacity float32) error {
+	for _, tl := range layers {
+		visible, opacity := visible && tl.Visible != "0", opacity
+		if tl.Opacity != "" {
+			var o float32
+			if _, err := fmt.Sscan(tl.Opacity, &o); err != nil {
+				return fmt.Errorf("layer %q: bad opacity %q", tl.Name, tl.Opacity)
+			}
+			opacity *= o
+		}
+
+		switch tl.XMLName.Local {
+		case "group":
+			if err := m.addTMXLayers(tl.Layers, visible, opacity); err != nil {
+				return err
+			}
+		case "layer":
+			props, err := tmxProperties(tl.Properties)
+			if err != nil {
+				return fmt.Errorf("layer %q: %w", tl.Name, err)
+			}
+			l := newLayer(tl.Name, visible, opacity, props)
+			if err := l.fillTMX(tl); err != nil {
+				return err
+			}
+			m.Layers = append(m.Layers, l)
+		}
+	}
+	return nil
+}
+
+func (l *Layer) fillTMX(tl tmxLayer) error {
+	d := tl.Data
+	if len(d.Chunks) == 0 {
+		gids, err := tmxTiles(d.Encoding, d.Compression, d.Text, d.Tiles)
+		if err != nil {
+			return fmt.Errorf("layer %q: %w", l.Name, err)
+		}
+		return l.fill(tl.X, tl.Y, tl.Width, tl.Height, gids)
+	}
+	for _, c := range d.Chunks {
+		gids, err := tmxTiles(d.Encoding, d.Compression, c.Text, c.Tiles)
+		if err != nil {
+			return fmt.Errorf("layer %q: %w", l.Name, err)
+		}
+		if err := l.fill(c.X, c.Y, c.Width, c.Height, gids); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tmxTiles decodes layer data, which is a list of <tile> elements unless the
+// layer has an encoding.
+func tmxTiles(encoding, compression, text string, cells []tmxCell) ([]GID, error) {
+	if encoding != "" {
+		return decodeTiles(encoding, compression, text)
+	}
+	gids := make([]GID, len(cells))
+	for i, c := range cells {
+		gids[i] = c.GID
+	}
+	return gids, nil
+}
+
+func parseTSX(name string, data []byte) (*Tileset, error) {
+	var tt tmxTileset
+	if err := xml.Unmarshal(data, &tt); err != nil {
+		return nil, err
+	}
+	return tt.tileset(name)
+}
+
+// tileset converts a tileset read from the file at name.
+func (tt *tmxTileset) tileset(name string) (*Tileset, error) {
+	ts := &Tileset{
+		Name:        tt.Name,
+		TileWidth:   tt.TileWidth,
+		TileHeight:  tt.TileHeight,
+		Columns:     tt.Columns,
+		TileCount:   tt.TileCount,
+		Spacing:     tt.Spacing,
+		Margin:      tt.Margin,
+		ImageWidth:  tt.Image.Width,
+		ImageHeight: tt.Image.Height,
+		Tiles:       make(map[int]*TileInfo),
+	}
+
+	var err error
+	if tt.Image.Source != "" {
+		if ts.Image, err = resolve(name, tt.Image.Source); err != nil {
+			return nil, err
+		}
+	}
+	if ts.Properties, err = tmxProperties(tt.Properties); err != nil {
+		return nil, err
+	}
+
+	for _, t := range tt.Tiles {
+		props, err := tmxProperties(t.Properties)
+		if err != nil {
+			return nil, fmt.Errorf("tile %d: %w", t.ID, err)
+		}
+		info := &TileInfo{Properties: props, Probability: 1}
+		if t.Probability != nil {
+			info.Probability = *t.Probability
+		}
+		for _, f := range t.Animation {
+			info.Animation = append(info.Animation, AnimationFrame{
+				TileID:   f.TileID,
+				Duration: time.Duration(f.Duration) * time.Millisecond,
+			})
+		}
+		ts.Tiles[t.ID] = info
+	}
+
+	for _, tw := range tt.WangSets {
+		ws := &WangSet{Name: tw.Name, Type: tw.Type}
+		for _, wt := range tw.WangTiles {
+			tile := WangTile{TileID: wt.TileID}
+			colors := strings.Split(wt.WangID, ",")
+			if len(colors) != len(tile.WangID) {
+				return nil, fmt.Errorf("wang set %q: tile %d: bad wang ID %q", tw.Name, wt.TileID, wt.WangID)
+			}
+			for i, c := range colors {
+				if tile.WangID[i], err = strconv.Atoi(strings.TrimSpace(c)); err != nil {
+					return nil, fmt.Errorf("wang set %q: tile %d: bad wang ID %q", tw.Name, wt.TileID, wt.WangID)
+				}
+			}
+			ws.Tiles = append(ws.Tiles, tile)
+		}
+		ts.WangSets = append(ts.WangSets, ws)
+	}
+	return ts, nil
+}
+
+func tmxProperties(list []tmxProperty) (Properties, error) {
+	if len(list) == 0 {
+		return nil, nil
+	}
+	props := make(Properties, len(list))
+	for _, p := range list {
+		text := p.Value
+		if text == "" {
+			text = strings.TrimSpace(p.Text)
+		}
+		value, err := property(p.Type, text)
+		if err != nil {
+			return nil, fmt.Errorf("property %q: %w", p.Name, err)
+		}
+		props[p.Name] = value
+	}
+	return props, nil
+}